@@ -0,0 +1,52 @@
+package memgc
+
+import "time"
+
+// AllocPoint is one interval of an AllocRates series.
+type AllocPoint struct {
+	// End of the interval.
+	Timestamp   time.Time
+	BytesPerSec float64
+	// GC cycles that completed during the interval.
+	GCs uint32
+}
+
+// AllocRate returns how many bytes per second the process allocated
+// over the next window, from TotalAlloc at either end. The allocation
+// rate, not the heap size, is what sets how often the GC runs: at a
+// fixed GOGC, twice the rate means twice the cycles.
+func AllocRate(window time.Duration) float64 {
+	start := readSample()
+	time.Sleep(window)
+	return allocPoint(start, readSample()).BytesPerSec
+}
+
+// AllocRates samples the allocation rate every interval (at least
+// MinSampleInterval) for window and returns one AllocPoint per interval,
+// so spikes in the rate can be lined up with the GC cycles they cause.
+// Run the workload on another goroutine while it measures.
+func AllocRates(window, interval time.Duration) []AllocPoint {
+	interval = max(interval, MinSampleInterval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var points []AllocPoint
+	prev := readSample()
+	end := prev.Timestamp.Add(window)
+	for prev.Timestamp.Before(end) {
+		<-ticker.C
+		cur := readSample()
+		points = append(points, allocPoint(prev, cur))
+		prev = cur
+	}
+	return points
+}
+
+func allocPoint(from, to MemSample) AllocPoint {
+	secs := to.Timestamp.Sub(from.Timestamp).Seconds()
+	return AllocPoint{
+		Timestamp:   to.Timestamp,
+		BytesPerSec: float64(to.TotalAlloc-from.TotalAlloc) / secs,
+		GCs:         to.NumGC - from.NumGC,
+	}
+}
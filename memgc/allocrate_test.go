@@ -0,0 +1,73 @@
+package memgc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllocPoint(t *testing.T) {
+	t0 := time.Unix(100, 0)
+	tests := []struct {
+		name     string
+		from, to MemSample
+		wantRate float64
+		wantGCs  uint32
+	}{
+		{"idle", MemSample{TotalAlloc: 1000, Timestamp: t0},
+			MemSample{TotalAlloc: 1000, Timestamp: t0.Add(time.Second)}, 0, 0},
+		{"1MB in 500ms", MemSample{TotalAlloc: 0, NumGC: 3, Timestamp: t0},
+			MemSample{TotalAlloc: 1 << 20, NumGC: 5, Timestamp: t0.Add(500 * time.Millisecond)}, 2 << 20, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := allocPoint(tt.from, tt.to)
+			if p.BytesPerSec != tt.wantRate || p.GCs != tt.wantGCs || !p.Timestamp.Equal(tt.to.Timestamp) {
+				t.Fatalf("allocPoint = %+v, want %v B/s and %d GCs", p, tt.wantRate, tt.wantGCs)
+			}
+		})
+	}
+}
+
+var allocSink []byte
+
+// allocAtRate allocates chunk bytes every tick until stop closes.
+func allocAtRate(chunk int, tick time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(tick)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			allocSink = make([]byte, chunk)
+		}
+	}
+}
+
+func TestAllocRateMeasuresKnownLoop(t *testing.T) {
+	const chunk, tick = 256 << 10, 2 * time.Millisecond
+	want := float64(chunk) / tick.Seconds()
+	stop := make(chan struct{})
+	go allocAtRate(chunk, tick, stop)
+	got := AllocRate(300 * time.Millisecond)
+	close(stop)
+
+	// Timer slack only ever slows the loop down; other allocations in
+	// the process only add a little.
+	if got < 0.5*want || got > 1.2*want {
+		t.Fatalf("AllocRate = %.0f B/s, want about %.0f", got, want)
+	}
+}
+
+func TestAllocRatesSeries(t *testing.T) {
+	points := AllocRates(100*time.Millisecond, time.Millisecond)
+	// The interval is raised to MinSampleInterval.
+	if n := len(points); n < 5 || n > 11 {
+		t.Fatalf("got %d points over 100ms, want about 10", n)
+	}
+	for i := 1; i < len(points); i++ {
+		if !points[i].Timestamp.After(points[i-1].Timestamp) {
+			t.Fatalf("point %d is not after point %d", i, i-1)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package memgc
+
+import "runtime"
+
+// BallastResult compares a workload with and without a memory ballast.
+type BallastResult struct {
+	Size    int
+	Plain   GCRun
+	Ballast GCRun
+}
+
+// WithBallast runs workload twice, first as is and then with a live
+// sizeBytes []byte ballast allocated beforehand, and reports the GC cost
+// of each. The ballast counts towards the live heap, so the next GC
+// target (live heap × (1+GOGC/100)) starts that much higher and the
+// workload triggers fewer GCs. The ballast is never written, so on most
+// systems it costs address space rather than resident memory.
+//
+// This is the classic trick from before Go 1.19. Today
+// debug.SetMemoryLimit (see RunUnderMemLimit), usually with a high
+// GOGC, gets the same effect without pinning a dummy allocation and is
+// the preferred approach.
+func WithBallast(sizeBytes int, workload func()) BallastResult {
+	r := BallastResult{Size: sizeBytes}
+	r.Plain = measureGC(workload)
+
+	ballast := make([]byte, sizeBytes)
+	r.Ballast = measureGC(workload)
+	runtime.KeepAlive(ballast)
+	return r
+}
@@ -0,0 +1,35 @@
+package memgc
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestWithBallastTriggersFewerGCs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs step2's workload twice")
+	}
+	defer debug.SetGCPercent(debug.SetGCPercent(100))
+	tests := []struct {
+		name string
+		size int
+	}{
+		{"64MB", 64 << 20},
+		{"256MB", 256 << 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := WithBallast(tt.size, func() { _ = RetainedBursts() })
+			t.Logf("plain %d GCs, ballast %d GCs", r.Plain.NumGC, r.Ballast.NumGC)
+			if r.Size != tt.size {
+				t.Errorf("Size = %d, want %d", r.Size, tt.size)
+			}
+			if r.Plain.NumGC == 0 {
+				t.Fatal("the plain run triggered no GC")
+			}
+			if r.Ballast.NumGC >= r.Plain.NumGC {
+				t.Fatalf("ballast run triggered %d GCs, plain %d; want fewer", r.Ballast.NumGC, r.Plain.NumGC)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+// Command allocrate shows the allocation rate across step2's five
+// bursts with memgc.AllocRates: high while a burst runs, near zero in
+// the 100ms sleeps, with the GC cycles clustered in the bursts.
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"memgc"
+)
+
+func main() {
+	done := make(chan []*[]byte)
+	go func() { done <- memgc.RetainedBursts() }()
+
+	start := time.Now()
+	for _, p := range memgc.AllocRates(600*time.Millisecond, 25*time.Millisecond) {
+		fmt.Printf("%6v: %8.1f MB/s, GC runs: %d\n",
+			p.Timestamp.Sub(start).Round(time.Millisecond), p.BytesPerSec/1e6, p.GCs)
+	}
+	fmt.Printf("Retained %d items\n", len(<-done))
+}
@@ -0,0 +1,17 @@
+// Command ballast runs step2's workload with and without a 256MB memory
+// ballast and prints how GC frequency changes.
+package main
+
+import (
+	"fmt"
+
+	"memgc"
+)
+
+func main() {
+	r := memgc.WithBallast(256<<20, func() {
+		memgc.RetainedBursts()
+	})
+	fmt.Printf("No ballast:    %3d GCs, total pause %v\n", r.Plain.NumGC, r.Plain.TotalPause)
+	fmt.Printf("%dMB ballast: %3d GCs, total pause %v\n", r.Size>>20, r.Ballast.NumGC, r.Ballast.TotalPause)
+}
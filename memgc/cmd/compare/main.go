@@ -0,0 +1,19 @@
+// Command compare runs the escaping vs stack comparison from step3, plus
+// the pooled variant, through memgc.RunComparison and prints the
+// structured report.
+package main
+
+import (
+	"fmt"
+
+	"memgc"
+)
+
+func main() {
+	// Many small slices, like step3.
+	inputs := make([][]int, 10_000)
+	for i := range inputs {
+		inputs[i] = []int{i * 10, i*10 + 1, i*10 + 2}
+	}
+	fmt.Println(memgc.RunComparison(inputs))
+}
@@ -0,0 +1,19 @@
+// Command gogc runs step2's workload at several GOGC settings in one
+// process and prints the GC count vs pause tradeoff table.
+package main
+
+import (
+	"fmt"
+
+	"memgc"
+)
+
+func main() {
+	results := memgc.RunGOGCExperiment([]int{10, 50, 100, 200}, func() {
+		memgc.RetainedBursts()
+	})
+	fmt.Println("GOGC  GCs  Total pause  Run time")
+	for _, r := range results {
+		fmt.Printf("%4d  %3d  %11v  %v\n", r.Percent, r.NumGC, r.TotalPause, r.Duration)
+	}
+}
@@ -0,0 +1,17 @@
+// Command memlimit runs step2's workload with and without a 64MB soft
+// memory limit and prints how GC frequency changes.
+package main
+
+import (
+	"fmt"
+
+	"memgc"
+)
+
+func main() {
+	r := memgc.RunUnderMemLimit(64<<20, func() {
+		memgc.RetainedBursts()
+	})
+	fmt.Printf("Unlimited: %3d GCs, total pause %v\n", r.Unlimited.NumGC, r.Unlimited.TotalPause)
+	fmt.Printf("%dMB limit: %3d GCs, total pause %v\n", r.Limit>>20, r.Limited.NumGC, r.Limited.TotalPause)
+}
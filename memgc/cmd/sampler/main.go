@@ -0,0 +1,29 @@
+// Command sampler watches the heap grow across step2's five allocation
+// bursts with memgc.StartSampler, instead of only seeing the endpoints.
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"memgc"
+)
+
+func main() {
+	stop, samples := memgc.StartSampler(50 * time.Millisecond)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		start := time.Now()
+		for s := range samples {
+			fmt.Printf("%6v: Heap %6.1f MB, GC runs: %3d, last pause: %v\n",
+				s.Timestamp.Sub(start).Round(time.Millisecond),
+				float64(s.HeapAlloc)/1e6, s.NumGC, s.LastPause)
+		}
+	}()
+
+	retained := memgc.RetainedBursts()
+	stop()
+	<-done
+	fmt.Printf("Retained %d items\n", len(retained))
+}
@@ -0,0 +1,26 @@
+// Command sizeclass shows how Go's allocator sorts objects into size
+// classes: a mix of 16-, 50-, 64- and 500-byte allocations, profiled
+// with memgc.SizeClassProfile.
+package main
+
+import (
+	"fmt"
+
+	"memgc"
+)
+
+var retained [][]byte
+
+func main() {
+	classes := memgc.SizeClassProfile(func() {
+		for i := 0; i < 10_000; i++ {
+			retained = append(retained,
+				make([]byte, 16), make([]byte, 50), make([]byte, 64), make([]byte, 500))
+		}
+	})
+	for _, c := range classes {
+		fmt.Printf("class %5d B: %6d mallocs, %6d frees, %8d bytes\n",
+			c.Size, c.Mallocs, c.Frees, c.Bytes())
+	}
+	fmt.Printf("Retained %d items\n", len(retained))
+}
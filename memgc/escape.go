@@ -0,0 +1,170 @@
+package memgc
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// A Variant turns a batch of ids into strings. RunComparison measures
+// how much each variant allocates.
+type Variant func(ids []int) []string
+
+// ProcessDataEscaping builds its results in a fresh slice that escapes
+// to the heap because it is returned.
+func ProcessDataEscaping(ids []int) []string {
+	// Escapes: outlives the call
+	results := make([]string, len(ids))
+	for i, id := range ids {
+		// Strings escape via fmt
+		results[i] = fmt.Sprintf("item-%d", id)
+	}
+	return results
+}
+
+// ProcessDataStack builds its results in a fixed-size array (assumed to
+// hold up to 100 items) and returns a slice view of it. Note that the
+// view outlives the call, so the compiler moves the array to the heap
+// anyway (see go build -gcflags=-m); RunComparison makes that visible.
+func ProcessDataStack(ids []int) []string {
+	var results [100]string
+	n := min(len(ids), len(results))
+	for i, id := range ids[:n] {
+		results[i] = fmt.Sprintf("item-%d", id)
+	}
+	return results[:n]
+}
+
+// Capacity of the pooled result arrays. Bigger results outgrow them
+// and aren't recycled.
+const pooledCap = 64
+
+// resultPool recycles ProcessDataPooled's result arrays. It holds
+// array pointers rather than slices: putting a slice in a Pool boxes it,
+// which is an allocation of its own.
+var resultPool = sync.Pool{
+	New: func() any { return new([pooledCap]string) },
+}
+
+// ProcessDataPooled builds its results in an array drawn from a
+// sync.Pool. It still escapes, but once the caller hands the result back
+// with ReleaseResult the next call reuses it instead of allocating. The
+// strings themselves are new each time.
+func ProcessDataPooled(ids []int) []string {
+	results := resultPool.Get().(*[pooledCap]string)[:0]
+	for _, id := range ids {
+		results = append(results, fmt.Sprintf("item-%d", id))
+	}
+	return results
+}
+
+// ReleaseResult recycles a slice returned by ProcessDataPooled. The
+// caller must not use it afterwards.
+func ReleaseResult(results []string) {
+	if cap(results) != pooledCap {
+		// Outgrew the pooled array; let the GC have it.
+		return
+	}
+	arr := (*[pooledCap]string)(results[:pooledCap])
+	clear(arr[:])
+	resultPool.Put(arr)
+}
+
+// Result is what one variant cost over a whole input set.
+type Result struct {
+	// Heap objects allocated (MemStats.Mallocs delta).
+	Allocs uint64
+	// Heap bytes allocated (MemStats.TotalAlloc delta).
+	Bytes uint64
+	// Wall-clock time for the run.
+	Duration time.Duration
+}
+
+// Report compares the escaping, stack and pooled variants on the same
+// inputs.
+type Report struct {
+	Escaping Result
+	Stack    Result
+	Pooled   Result
+}
+
+func (r Report) String() string {
+	return fmt.Sprintf("Escaping: %d allocs, %d bytes in %v\n"+
+		"Stack:    %d allocs, %d bytes in %v\n"+
+		"Pooled:   %d allocs, %d bytes in %v\n"+
+		"Escaping - Stack: %d allocs, %d bytes\n"+
+		"Escaping - Pooled: %d allocs, %d bytes",
+		r.Escaping.Allocs, r.Escaping.Bytes, r.Escaping.Duration,
+		r.Stack.Allocs, r.Stack.Bytes, r.Stack.Duration,
+		r.Pooled.Allocs, r.Pooled.Bytes, r.Pooled.Duration,
+		int64(r.Escaping.Allocs)-int64(r.Stack.Allocs),
+		int64(r.Escaping.Bytes)-int64(r.Stack.Bytes),
+		int64(r.Escaping.Allocs)-int64(r.Pooled.Allocs),
+		int64(r.Escaping.Bytes)-int64(r.Pooled.Bytes))
+}
+
+// RunComparison runs ProcessDataEscaping and ProcessDataStack over
+// inputs, keeping every result live like a caller collecting them would,
+// and ProcessDataPooled, releasing each result once used as a pooling
+// caller must, and reports what each cost.
+func RunComparison(inputs [][]int) Report {
+	return Report{
+		Escaping: Measure(ProcessDataEscaping, inputs),
+		Stack:    Measure(ProcessDataStack, inputs),
+		Pooled:   MeasurePooled(inputs),
+	}
+}
+
+// MeasurePooled is Measure for ProcessDataPooled: each result is handed
+// back with ReleaseResult before the next call, so after the first few
+// calls the pool supplies every slice. A warm-up pass fills the pool
+// first, so what's measured is the steady state.
+func MeasurePooled(inputs [][]int) Result {
+	for _, ids := range inputs[:min(len(inputs), 100)] {
+		ReleaseResult(ProcessDataPooled(ids))
+	}
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	start := time.Now()
+	n := 0
+	for _, ids := range inputs {
+		results := ProcessDataPooled(ids)
+		n += len(results)
+		ReleaseResult(results)
+	}
+	dur := time.Since(start)
+
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(n)
+	return Result{
+		Allocs:   after.Mallocs - before.Mallocs,
+		Bytes:    after.TotalAlloc - before.TotalAlloc,
+		Duration: dur,
+	}
+}
+
+// Measure runs v over every input and reports its allocations and time,
+// read from runtime.MemStats before and after. It forces a GC first so
+// one run's garbage doesn't count against the next.
+func Measure(v Variant, inputs [][]int) Result {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	start := time.Now()
+	all := make([][]string, 0, len(inputs))
+	for _, ids := range inputs {
+		all = append(all, v(ids))
+	}
+	dur := time.Since(start)
+
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(all)
+	return Result{
+		Allocs:   after.Mallocs - before.Mallocs,
+		Bytes:    after.TotalAlloc - before.TotalAlloc,
+		Duration: dur,
+	}
+}
@@ -0,0 +1,109 @@
+package memgc
+
+import "testing"
+
+// benchInputs is a batch of 50 ids, the size the step3 demo uses.
+var benchInputs = func() []int {
+	ids := make([]int, 50)
+	for i := range ids {
+		ids[i] = i
+	}
+	return ids
+}()
+
+var benchSink []string
+
+func BenchmarkProcessData(b *testing.B) {
+	for _, bb := range []struct {
+		name string
+		v    Variant
+	}{
+		{"Escaping", ProcessDataEscaping},
+		{"Stack", ProcessDataStack},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				benchSink = bb.v(benchInputs)
+			}
+		})
+	}
+}
+
+func TestVariantsAgree(t *testing.T) {
+	for _, n := range []int{0, 1, 50, 100} {
+		ids := make([]int, n)
+		for i := range ids {
+			ids[i] = i * 7
+		}
+		esc, stack := ProcessDataEscaping(ids), ProcessDataStack(ids)
+		if len(esc) != n || len(stack) != n {
+			t.Fatalf("n=%d: got %d and %d results", n, len(esc), len(stack))
+		}
+		for i := range esc {
+			if esc[i] != stack[i] {
+				t.Fatalf("n=%d: result %d: %q vs %q", n, i, esc[i], stack[i])
+			}
+		}
+	}
+}
+
+func TestRunComparison(t *testing.T) {
+	inputs := make([][]int, 200)
+	for i := range inputs {
+		inputs[i] = benchInputs
+	}
+	r := RunComparison(inputs)
+	for name, res := range map[string]Result{"Escaping": r.Escaping, "Stack": r.Stack} {
+		// At least the 50 strings per input.
+		if res.Allocs < 50*200 || res.Bytes == 0 {
+			t.Errorf("%s: %+v, want the per-item allocations counted", name, res)
+		}
+	}
+}
+
+func BenchmarkProcessDataPooled(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		ReleaseResult(ProcessDataPooled(benchInputs))
+	}
+}
+
+func TestPooledAllocatesLessThanEscaping(t *testing.T) {
+	inputs := make([][]int, 500)
+	for i := range inputs {
+		inputs[i] = benchInputs
+	}
+	esc, pooled := Measure(ProcessDataEscaping, inputs), MeasurePooled(inputs)
+	// Escaping allocates the slice plus 50 strings per call; pooled only
+	// the strings once warm.
+	if pooled.Allocs >= esc.Allocs {
+		t.Fatalf("pooled made %d mallocs, escaping %d; want fewer", pooled.Allocs, esc.Allocs)
+	}
+	if raceEnabled {
+		return // the pool drops Puts at random, so misses add mallocs
+	}
+	if perCall := float64(pooled.Allocs) / float64(len(inputs)); perCall > 50.5 {
+		t.Fatalf("pooled made %.1f mallocs per call, want at most the 50 strings", perCall)
+	}
+}
+
+func TestReleaseResultRecycles(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+	}{
+		{"fits the pooled array", pooledCap},
+		{"outgrows it", pooledCap + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := make([]int, tt.n)
+			got := ProcessDataPooled(ids)
+			if len(got) != tt.n || got[tt.n-1] != "item-0" {
+				t.Fatalf("got %d results ending %q", len(got), got[len(got)-1])
+			}
+			ReleaseResult(got) // must not panic either way
+		})
+	}
+}
@@ -0,0 +1,3 @@
+module memgc
+
+go 1.24.1
@@ -0,0 +1,57 @@
+package memgc
+
+import (
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// GCRun is the GC cost of one workload run.
+type GCRun struct {
+	// GCs completed during the run.
+	NumGC uint32
+	// Total stop-the-world pause during the run.
+	TotalPause time.Duration
+	// Wall-clock time for the run.
+	Duration time.Duration
+}
+
+// GOGCResult is what one workload run cost at a given GOGC percentage.
+type GOGCResult struct {
+	Percent int
+	GCRun
+}
+
+// RunGOGCExperiment runs workload once per GOGC percentage, set with
+// debug.SetGCPercent, and reports the GC count and pause time of each
+// run: the GOGC=100 vs GOGC=10 tradeoff from step2 in one process. A
+// negative percentage turns the GC off. The original setting (which
+// SetGCPercent hands back on the first call) is restored afterwards.
+func RunGOGCExperiment(percentages []int, workload func()) []GOGCResult {
+	results := make([]GOGCResult, 0, len(percentages))
+	for i, pct := range percentages {
+		prev := debug.SetGCPercent(pct)
+		if i == 0 {
+			defer debug.SetGCPercent(prev)
+		}
+		results = append(results, GOGCResult{Percent: pct, GCRun: measureGC(workload)})
+	}
+	return results
+}
+
+// measureGC runs workload from a freshly collected heap and reports the
+// GCs and pause time it caused.
+func measureGC(workload func()) GCRun {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	workload()
+	dur := time.Since(start)
+	runtime.ReadMemStats(&after)
+	return GCRun{
+		NumGC:      after.NumGC - before.NumGC,
+		TotalPause: time.Duration(after.PauseTotalNs - before.PauseTotalNs),
+		Duration:   dur,
+	}
+}
@@ -0,0 +1,5 @@
+// Package memgc collects the memory and GC experiments from the
+// step-by-step demos in this module as functions that return structured
+// results, so they can be reused from tests and other programs instead
+// of only printing lines from main.
+package memgc
@@ -0,0 +1,21 @@
+// Package memgctest holds test helpers for the allocation behaviour the
+// memgc demos explore.
+package memgctest
+
+import "testing"
+
+// allocRuns is how many times AssertNoHeapAlloc calls fn per measurement.
+const allocRuns = 100
+
+// AssertNoHeapAlloc fails t if fn allocates on the heap. It measures with
+// testing.AllocsPerRun, which warms fn up, pins GOMAXPROCS to 1 while it
+// counts, and averages over allocRuns calls; the average is rounded
+// down, so the odd allocation by the runtime or another goroutine
+// doesn't cause a flake. Use it to pin escape-analysis results, e.g.
+// that a helper keeps its buffers on the stack.
+func AssertNoHeapAlloc(t testing.TB, fn func()) {
+	t.Helper()
+	if n := testing.AllocsPerRun(allocRuns, fn); n > 0 {
+		t.Errorf("fn made %v heap allocations per call, want 0", n)
+	}
+}
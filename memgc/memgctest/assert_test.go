@@ -0,0 +1,48 @@
+package memgctest
+
+import (
+	"runtime"
+	"testing"
+)
+
+// recorder is a testing.TB that notes failures instead of reporting them.
+type recorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recorder) Errorf(string, ...any) { r.failed = true }
+
+var sink []byte
+
+func TestAssertNoHeapAlloc(t *testing.T) {
+	tests := []struct {
+		name     string
+		fn       func()
+		wantFail bool
+	}{
+		{"stack only", func() {
+			var buf [64]byte
+			buf[0] = 1
+			_ = buf
+		}, false},
+		{"escapes", func() { sink = make([]byte, 64) }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{TB: t}
+			AssertNoHeapAlloc(r, tt.fn)
+			if r.failed != tt.wantFail {
+				t.Fatalf("failed = %v, want %v", r.failed, tt.wantFail)
+			}
+		})
+	}
+}
+
+func TestAssertNoHeapAllocRestoresGOMAXPROCS(t *testing.T) {
+	want := runtime.GOMAXPROCS(0)
+	AssertNoHeapAlloc(t, func() {})
+	if got := runtime.GOMAXPROCS(0); got != want {
+		t.Fatalf("GOMAXPROCS after AssertNoHeapAlloc = %d, want %d", got, want)
+	}
+}
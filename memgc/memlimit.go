@@ -0,0 +1,33 @@
+package memgc
+
+import (
+	"math"
+	"runtime/debug"
+)
+
+// MemLimitResult compares a workload with and without a soft memory
+// limit.
+type MemLimitResult struct {
+	Limit     int64
+	Unlimited GCRun
+	Limited   GCRun
+}
+
+// RunUnderMemLimit runs workload twice, first with no memory limit and
+// then with debug.SetMemoryLimit(limitBytes), and reports the GC cost of
+// each. Under the limit the runtime collects more aggressively as the
+// heap nears it, even though GOGC is unchanged, which is the Go 1.19
+// soft memory limit at work. For step2's ~110MB workload
+// (RetainedBursts) try a limit around 64MB. The previous limit
+// (math.MaxInt64, i.e. none, unless GOMEMLIMIT is set) is restored
+// afterwards.
+func RunUnderMemLimit(limitBytes int64, workload func()) MemLimitResult {
+	prev := debug.SetMemoryLimit(math.MaxInt64)
+	defer debug.SetMemoryLimit(prev)
+
+	r := MemLimitResult{Limit: limitBytes}
+	r.Unlimited = measureGC(workload)
+	debug.SetMemoryLimit(limitBytes)
+	r.Limited = measureGC(workload)
+	return r
+}
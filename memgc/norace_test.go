@@ -0,0 +1,5 @@
+//go:build !race
+
+package memgc
+
+const raceEnabled = false
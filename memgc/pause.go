@@ -0,0 +1,89 @@
+package memgc
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Bucket counts GC pauses no longer than UpTo (and longer than the
+// previous bucket's bound). The last bucket's UpTo is math.MaxInt64.
+type Bucket struct {
+	UpTo  time.Duration
+	Count int
+}
+
+// Histogram describes the GC pauses the runtime still remembers.
+type Histogram struct {
+	// Real pauses included: min(NumGC, 256).
+	Count   int
+	Buckets []Bucket
+	// Pause quantiles.
+	P50, P95, P99 time.Duration
+}
+
+// Upper bounds of the Histogram buckets; a final catch-all follows.
+var pauseBounds = []time.Duration{
+	10 * time.Microsecond,
+	50 * time.Microsecond,
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+}
+
+func (h Histogram) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Pause quantiles (from %d samples): 50%%=%v, 95%%=%v, 99%%=%v",
+		h.Count, h.P50, h.P95, h.P99)
+	for _, bk := range h.Buckets {
+		if bk.UpTo == math.MaxInt64 {
+			fmt.Fprintf(&b, "\n  >%-8v %d", pauseBounds[len(pauseBounds)-1], bk.Count)
+		} else {
+			fmt.Fprintf(&b, "\n  <=%-7v %d", bk.UpTo, bk.Count)
+		}
+	}
+	return b.String()
+}
+
+// GCPauseHistogram reads runtime.MemStats and buckets the recorded GC
+// pauses. PauseNs is a circular buffer of the most recent 256 pauses and
+// is zero-padded until that many GCs have run, so only the min(NumGC,
+// 256) real slots are used; otherwise the padding drags the low
+// quantiles down to zero.
+func GCPauseHistogram() Histogram {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return pauseHistogram(&stats)
+}
+
+func pauseHistogram(stats *runtime.MemStats) Histogram {
+	n := int(min(stats.NumGC, uint32(len(stats.PauseNs))))
+	pauses := make([]time.Duration, n)
+	for i := range n {
+		// Most recent first: PauseNs[(NumGC+255)%256] is the latest.
+		idx := (int(stats.NumGC) - 1 - i + len(stats.PauseNs)) % len(stats.PauseNs)
+		pauses[i] = time.Duration(stats.PauseNs[idx])
+	}
+	sort.Slice(pauses, func(i, j int) bool { return pauses[i] < pauses[j] })
+
+	h := Histogram{Count: n, Buckets: make([]Bucket, len(pauseBounds)+1)}
+	for i, bound := range pauseBounds {
+		h.Buckets[i].UpTo = bound
+	}
+	h.Buckets[len(pauseBounds)].UpTo = math.MaxInt64
+	for _, d := range pauses {
+		i := sort.Search(len(pauseBounds), func(i int) bool { return d <= pauseBounds[i] })
+		h.Buckets[i].Count++
+	}
+	if n > 0 {
+		h.P50 = pauses[n/2]
+		h.P95 = pauses[int(float64(n)*0.95)]
+		h.P99 = pauses[int(float64(n)*0.99)]
+	}
+	return h
+}
@@ -0,0 +1,63 @@
+package memgc
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestGCPauseHistogramCountsRealPauses(t *testing.T) {
+	for range 5 {
+		runtime.GC()
+	}
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	h := pauseHistogram(&stats)
+
+	if want := int(min(stats.NumGC, 256)); h.Count != want {
+		t.Fatalf("Count = %d, want min(NumGC, 256) = %d", h.Count, want)
+	}
+	total := 0
+	for _, b := range h.Buckets {
+		total += b.Count
+	}
+	if total != h.Count {
+		t.Fatalf("buckets hold %d pauses, want %d", total, h.Count)
+	}
+	if h.P50 > h.P95 || h.P95 > h.P99 {
+		t.Fatalf("quantiles out of order: %v %v %v", h.P50, h.P95, h.P99)
+	}
+}
+
+func TestPauseHistogramSkipsPadding(t *testing.T) {
+	tests := []struct {
+		name      string
+		numGC     uint32
+		pauses    map[int]time.Duration // PauseNs slot -> pause
+		wantCount int
+		wantP50   time.Duration
+	}{
+		{"no GCs", 0, nil, 0, 0},
+		{"few GCs, zero padding ignored", 3,
+			map[int]time.Duration{0: 20 * time.Microsecond, 1: 30 * time.Microsecond, 2: 40 * time.Microsecond},
+			3, 30 * time.Microsecond},
+		{"wrapped buffer", 300,
+			map[int]time.Duration{43: time.Millisecond},
+			256, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := runtime.MemStats{NumGC: tt.numGC}
+			for i, d := range tt.pauses {
+				stats.PauseNs[i] = uint64(d)
+			}
+			h := pauseHistogram(&stats)
+			if h.Count != tt.wantCount {
+				t.Fatalf("Count = %d, want %d", h.Count, tt.wantCount)
+			}
+			if h.P50 != tt.wantP50 {
+				t.Fatalf("P50 = %v, want %v", h.P50, tt.wantP50)
+			}
+		})
+	}
+}
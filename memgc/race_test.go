@@ -0,0 +1,7 @@
+//go:build race
+
+package memgc
+
+// raceEnabled reports whether tests run under the race detector, which
+// makes sync.Pool drop a share of Puts on purpose.
+const raceEnabled = true
@@ -0,0 +1,102 @@
+package memgc
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+)
+
+// ReclaimResult holds heap readings from RunWithReclaim's phases.
+type ReclaimResult struct {
+	// HeapAlloc after a forced GC with everything still rooted: nothing
+	// could be reclaimed.
+	Rooted uint64
+	// HeapAlloc after dropping the references and forcing another GC.
+	Unrooted uint64
+
+	// HeapReleased (bytes handed back to the OS) after the second GC and
+	// after debug.FreeOSMemory. A GC frees memory to the Go heap; only
+	// the scavenger or FreeOSMemory returns it to the OS.
+	Released       uint64
+	ReleasedFreeOS uint64
+}
+
+// RunWithReclaim contrasts step2's "rooted, no reclaim" ending with the
+// unrooted case. It runs RetainedBursts and forces a GC while the result
+// is still referenced, then drops the reference and forces another, and
+// finally calls debug.FreeOSMemory to return the freed pages to the OS.
+func RunWithReclaim() ReclaimResult {
+	var stats runtime.MemStats
+	var r ReclaimResult
+
+	retained := RetainedBursts()
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	r.Rooted = stats.HeapAlloc
+	runtime.KeepAlive(retained)
+
+	// Drop the only reference: everything becomes garbage.
+	retained = nil
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	r.Unrooted = stats.HeapAlloc
+	r.Released = stats.HeapReleased
+
+	debug.FreeOSMemory()
+	runtime.ReadMemStats(&stats)
+	r.ReleasedFreeOS = stats.HeapReleased
+	return r
+}
+
+// FinalizerResult counts the finalizers TrackReclaim saw fire.
+type FinalizerResult struct {
+	// Objects allocated, each with a finalizer.
+	Objects int
+	// Finalizers run after a forced GC with every object still rooted:
+	// always 0, as a reachable object is never collected.
+	WhileRooted int
+	// Finalizers run once the objects were dropped, by the time all of
+	// them had fired or the timeout passed.
+	AfterDrop int
+	// How long the finalizers took to catch up after the drop.
+	Elapsed time.Duration
+}
+
+// finalized is a heap object big enough to get an allocation of its own;
+// the runtime may never finalize tiny objects that share a block.
+type finalized struct {
+	_ [64]byte
+}
+
+// TrackReclaim makes the moment of collection visible: it allocates n
+// objects with runtime.SetFinalizer, forces a GC while they are rooted
+// (no finalizer fires), then drops them and forces GCs until every
+// finalizer has run or timeout passes. Finalizers run asynchronously on
+// a single runtime goroutine after the GC that found the object dead,
+// so the count is polled rather than read straight after runtime.GC.
+func TrackReclaim(n int, timeout time.Duration) FinalizerResult {
+	var fired atomic.Int64
+	objs := make([]*finalized, n)
+	for i := range objs {
+		objs[i] = new(finalized)
+		runtime.SetFinalizer(objs[i], func(*finalized) { fired.Add(1) })
+	}
+
+	r := FinalizerResult{Objects: n}
+	runtime.GC()
+	// Give finalizers a moment to prove that none are queued.
+	time.Sleep(10 * time.Millisecond)
+	r.WhileRooted = int(fired.Load())
+	runtime.KeepAlive(objs)
+
+	objs = nil
+	start := time.Now()
+	for int(fired.Load()) < n && time.Since(start) < timeout {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	r.Elapsed = time.Since(start)
+	r.AfterDrop = int(fired.Load()) - r.WhileRooted
+	return r
+}
@@ -0,0 +1,38 @@
+package memgc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRunWithReclaimFreesHeap(t *testing.T) {
+	if testing.Short() {
+		t.Skip("allocates ~60MB over half a second")
+	}
+	r := RunWithReclaim()
+	t.Logf("rooted %d MB, unrooted %d MB", r.Rooted>>20, r.Unrooted>>20)
+
+	// 1,000,000 live 64-byte slices plus their headers.
+	const minDrop = 50 << 20
+	if r.Rooted < r.Unrooted || r.Rooted-r.Unrooted < minDrop {
+		t.Fatalf("heap went from %d to %d bytes, want a drop of at least %d", r.Rooted, r.Unrooted, minDrop)
+	}
+	if r.ReleasedFreeOS < r.Released {
+		t.Fatalf("HeapReleased fell from %d to %d after FreeOSMemory", r.Released, r.ReleasedFreeOS)
+	}
+}
+
+func TestTrackReclaim(t *testing.T) {
+	for _, n := range []int{1, 100, 1000} {
+		r := TrackReclaim(n, 5*time.Second)
+		if r.Objects != n {
+			t.Fatalf("Objects = %d, want %d", r.Objects, n)
+		}
+		if r.WhileRooted != 0 {
+			t.Fatalf("n=%d: %d finalizers ran while the objects were rooted", n, r.WhileRooted)
+		}
+		if r.AfterDrop != n {
+			t.Fatalf("n=%d: %d finalizers ran after the drop, want all of them", n, r.AfterDrop)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package memgc
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// MinSampleInterval is the shortest interval StartSampler accepts.
+// runtime.ReadMemStats stops the world, so sampling much faster than
+// this would perturb the very GC behaviour being watched.
+const MinSampleInterval = 10 * time.Millisecond
+
+// MemSample is one reading taken by StartSampler.
+type MemSample struct {
+	HeapAlloc uint64
+	// Cumulative bytes allocated, freed or not; see AllocRate.
+	TotalAlloc uint64
+	NumGC      uint32
+	// Duration of the most recent GC pause, or 0 before the first GC.
+	LastPause time.Duration
+	Timestamp time.Time
+}
+
+// StartSampler reads runtime.MemStats every interval (at least
+// MinSampleInterval) on a background goroutine and sends a MemSample
+// for each reading. Sampling waits for the reader, so a slow reader
+// slows the sampler rather than losing samples. Calling stop ends
+// sampling and closes samples; it is safe to call more than once.
+func StartSampler(interval time.Duration) (stop func(), samples <-chan MemSample) {
+	interval = max(interval, MinSampleInterval)
+	out := make(chan MemSample, 16)
+	done := make(chan struct{})
+	exited := make(chan struct{})
+
+	go func() {
+		defer close(exited)
+		defer close(out)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			select {
+			case out <- readSample():
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	stop = func() {
+		once.Do(func() { close(done) })
+		<-exited
+	}
+	return stop, out
+}
+
+func readSample() MemSample {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	s := MemSample{
+		HeapAlloc:  stats.HeapAlloc,
+		TotalAlloc: stats.TotalAlloc,
+		NumGC:      stats.NumGC,
+		Timestamp:  time.Now(),
+	}
+	if stats.NumGC > 0 {
+		s.LastPause = time.Duration(stats.PauseNs[(stats.NumGC+255)%256])
+	}
+	return s
+}
@@ -0,0 +1,44 @@
+package memgc
+
+import "runtime"
+
+// SizeClass is one row of a SizeClassProfile: a size class of Go's
+// allocator and how many objects were allocated and freed in it.
+type SizeClass struct {
+	// Largest object size, in bytes, that the class holds.
+	Size    uint32
+	Mallocs uint64
+	Frees   uint64
+}
+
+// Bytes is the space handed out for the class's allocations. Objects are
+// rounded up to Size, so this can exceed the bytes the program asked for.
+func (c SizeClass) Bytes() uint64 {
+	return uint64(c.Size) * c.Mallocs
+}
+
+// SizeClassProfile runs alloc and reports, from runtime.MemStats.BySize,
+// the size classes its heap allocations landed in, smallest first.
+// Classes nothing was allocated in or freed from are left out. Objects
+// over 32KB have no size class and are not counted, and allocations on
+// other goroutines while alloc runs are counted too.
+func SizeClassProfile(alloc func()) []SizeClass {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	alloc()
+	runtime.ReadMemStats(&after)
+
+	var classes []SizeClass
+	for i, c := range after.BySize {
+		b := before.BySize[i]
+		if c.Mallocs == b.Mallocs && c.Frees == b.Frees {
+			continue
+		}
+		classes = append(classes, SizeClass{
+			Size:    c.Size,
+			Mallocs: c.Mallocs - b.Mallocs,
+			Frees:   c.Frees - b.Frees,
+		})
+	}
+	return classes
+}
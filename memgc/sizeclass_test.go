@@ -0,0 +1,65 @@
+package memgc
+
+import "testing"
+
+var sizeSink [][]byte
+
+func TestSizeClassProfileMixedSizes(t *testing.T) {
+	const perSize = 1000
+	sizes := []int{16, 64, 512}
+	classes := SizeClassProfile(func() {
+		for _, size := range sizes {
+			for range perSize {
+				sizeSink = append(sizeSink, make([]byte, size))
+			}
+		}
+	})
+	sizeSink = nil
+
+	for i := 1; i < len(classes); i++ {
+		if classes[i].Size <= classes[i-1].Size {
+			t.Fatalf("classes not sorted by size: %v", classes)
+		}
+	}
+	bySize := make(map[uint32]SizeClass)
+	for _, c := range classes {
+		bySize[c.Size] = c
+	}
+	for _, size := range sizes {
+		c := bySize[uint32(size)]
+		if c.Mallocs < perSize {
+			t.Errorf("%d-byte class: %d mallocs, want at least %d", size, c.Mallocs, perSize)
+		}
+		if c.Bytes() != uint64(c.Size)*c.Mallocs {
+			t.Errorf("%d-byte class: Bytes() = %d", size, c.Bytes())
+		}
+	}
+}
+
+func TestSizeClassProfileRoundsUp(t *testing.T) {
+	tests := []struct {
+		size      int
+		wantClass uint32
+	}{
+		{17, 24},
+		{100, 112},
+		{600, 640},
+	}
+	for _, tt := range tests {
+		classes := SizeClassProfile(func() {
+			for range 500 {
+				sizeSink = append(sizeSink, make([]byte, tt.size))
+			}
+		})
+		sizeSink = nil
+		found := false
+		for _, c := range classes {
+			if c.Size == tt.wantClass && c.Mallocs >= 500 {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%d-byte objects: no %d-byte class with 500 mallocs in %v", tt.size, tt.wantClass, classes)
+		}
+	}
+}
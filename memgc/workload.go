@@ -0,0 +1,19 @@
+package memgc
+
+import "time"
+
+// RetainedBursts is step2's workload: five bursts of 200,000 64-byte
+// allocations (~12.8MB per burst), all kept live, with a 100ms pause
+// between bursts to let the GC breathe. It returns everything it
+// allocated, so the heap stays rooted until the caller drops it.
+func RetainedBursts() []*[]byte {
+	var retained []*[]byte
+	for epoch := 0; epoch < 5; epoch++ {
+		for i := 0; i < 200_000; i++ {
+			slice := make([]byte, 64)
+			retained = append(retained, &slice)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return retained
+}
@@ -0,0 +1,114 @@
+package toysched
+
+import (
+	"context"
+	"sync"
+)
+
+// Backend is the surface shared by the toy scheduler and the real Go
+// runtime, so the same workload can be run, and timed, on both.
+type Backend interface {
+	// Submit runs f as a new G (or goroutine).
+	Submit(f func()) error
+	// WaitIdle blocks until everything submitted so far has finished,
+	// or until ctx is done.
+	WaitIdle(ctx context.Context) error
+	// Stats reports counters; a backend fills in what it can measure.
+	Stats() SchedStats
+	// Close waits for outstanding work where it must and releases the
+	// backend.
+	Close() error
+}
+
+// ToyBackend runs work on a Scheduler's M/P machinery.
+type ToyBackend struct {
+	*Scheduler
+}
+
+// NewToyBackend wraps s as a Backend and starts its Ms.
+func NewToyBackend(s *Scheduler) *ToyBackend {
+	s.Run()
+	return &ToyBackend{s}
+}
+
+// Submit queues f on the toy scheduler, as Scheduler.Submit does.
+func (b *ToyBackend) Submit(f func()) error {
+	_, err := b.Scheduler.Submit(f)
+	return err
+}
+
+// Close lets the queued and running Gs finish, as NativeBackend.Close
+// does, refusing new ones meanwhile (see Shutdown with Drain), then
+// closes the scheduler (see Scheduler.Close).
+func (b *ToyBackend) Close() error {
+	b.Shutdown(Drain)
+	return b.Scheduler.Close()
+}
+
+// NativeBackend runs each function in a real goroutine, leaving the
+// scheduling to the Go runtime. Only Created, Completed and Failed in
+// its Stats are meaningful; the rest has no native counterpart here.
+type NativeBackend struct {
+	wg sync.WaitGroup
+
+	mu                         sync.Mutex
+	created, completed, failed int
+}
+
+// NewNativeBackend returns a NativeBackend ready for Submit.
+func NewNativeBackend() *NativeBackend {
+	return &NativeBackend{}
+}
+
+// Submit starts f in its own goroutine. A panic in f is recovered and
+// counted as Failed, as on the toy scheduler.
+func (b *NativeBackend) Submit(f func()) error {
+	b.mu.Lock()
+	b.created++
+	b.mu.Unlock()
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		defer func() {
+			failed := recover() != nil
+			b.mu.Lock()
+			if failed {
+				b.failed++
+			} else {
+				b.completed++
+			}
+			b.mu.Unlock()
+		}()
+		f()
+	}()
+	return nil
+}
+
+// WaitIdle blocks until every submitted goroutine has returned, or until
+// ctx is done (returning ctx.Err()).
+func (b *NativeBackend) WaitIdle(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats reports how many goroutines were started and how they ended.
+func (b *NativeBackend) Stats() SchedStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return SchedStats{Created: b.created, Completed: b.completed, Failed: b.failed}
+}
+
+// Close waits for every submitted goroutine to return.
+func (b *NativeBackend) Close() error {
+	b.wg.Wait()
+	return nil
+}
@@ -0,0 +1,97 @@
+package toysched_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"toysched"
+)
+
+func TestBackendsAgreeOnCounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend func() toysched.Backend
+	}{
+		{"toy", func() toysched.Backend {
+			s, _ := toysched.NewScheduler(4, 4, toysched.WithLogger(nil))
+			s.TickInterval = time.Millisecond
+			return toysched.NewToyBackend(s)
+		}},
+		{"native", func() toysched.Backend { return toysched.NewNativeBackend() }},
+	}
+	const n, panics = 100, 3
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.backend()
+			defer b.Close()
+
+			var ran atomic.Int32
+			for i := range n {
+				err := b.Submit(func() {
+					ran.Add(1)
+					if i < panics {
+						panic("boom")
+					}
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := b.WaitIdle(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if ran.Load() != n {
+				t.Errorf("%d functions ran, want %d", ran.Load(), n)
+			}
+			st := b.Stats()
+			if st.Created != n || st.Completed != n-panics || st.Failed != panics {
+				t.Errorf("Created/Completed/Failed = %d/%d/%d, want %d/%d/%d",
+					st.Created, st.Completed, st.Failed, n, n-panics, panics)
+			}
+		})
+	}
+}
+
+func TestBackendCloseWaitsForWork(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend func() toysched.Backend
+	}{
+		{"toy", func() toysched.Backend {
+			s, _ := toysched.NewScheduler(2, 2, toysched.WithLogger(nil))
+			s.TickInterval = time.Millisecond
+			s.ParkCooldown = time.Millisecond
+			return toysched.NewToyBackend(s)
+		}},
+		{"native", func() toysched.Backend { return toysched.NewNativeBackend() }},
+	}
+	const n = 20
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.backend()
+			var ran atomic.Int32
+			for range n {
+				err := b.Submit(func() {
+					time.Sleep(time.Millisecond)
+					ran.Add(1)
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := b.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if ran.Load() != n {
+				t.Fatalf("%d of %d functions ran before Close returned", ran.Load(), n)
+			}
+			if st := b.Stats(); st.Completed != n {
+				t.Fatalf("Completed = %d, want %d", st.Completed, n)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package toysched
+
+import (
+	"context"
+	"time"
+)
+
+// BenchResult is the outcome of one Benchmark run.
+type BenchResult struct {
+	NumP, NumM int
+	// Gs submitted and run to completion.
+	NumG int
+	// Wall-clock time from the first Submit until the scheduler was idle.
+	Elapsed  time.Duration
+	GsPerSec float64
+	// Percentage of Elapsed each P spent running Gs, indexed like
+	// Scheduler.Ps. The gap to 100% is scheduling overhead and idling.
+	PUtilization []float64
+}
+
+// Benchmark submits numG Gs running workPerG, waits (via WaitIdle) until
+// they have all finished, and reports the throughput. Sweeping P and M
+// counts and the size of workPerG shows where the toy's single lock
+// stops it scaling. The scheduler must already be running (see Run) and
+// otherwise idle; silence logging first (SetConsole(nil)), or logging
+// every event dominates the result. Gs that Submit refuses are
+// left out of NumG.
+func (s *Scheduler) Benchmark(numG int, workPerG func()) BenchResult {
+	s.mu.Lock()
+	r := BenchResult{NumP: len(s.Ps), NumM: len(s.Ms)}
+	before := make([]int64, len(s.Ps))
+	for i, p := range s.Ps {
+		before[i] = p.busy.Load()
+	}
+	s.mu.Unlock()
+
+	start := time.Now()
+	for range numG {
+		if _, err := s.Submit(workPerG); err == nil {
+			r.NumG++
+		}
+	}
+	s.WaitIdle(context.Background())
+	r.Elapsed = time.Since(start)
+
+	if r.Elapsed > 0 {
+		r.GsPerSec = float64(r.NumG) / r.Elapsed.Seconds()
+	}
+	r.PUtilization = make([]float64, len(before))
+	s.mu.Lock()
+	for i, p := range s.Ps[:len(before)] {
+		busy := p.busy.Load() - before[i]
+		r.PUtilization[i] = min(100*float64(busy)/float64(r.Elapsed), 100)
+	}
+	s.mu.Unlock()
+	return r
+}
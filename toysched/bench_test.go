@@ -0,0 +1,66 @@
+package toysched
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// spin burns roughly d of CPU, standing in for a G's work.
+func spin(d time.Duration) {
+	for start := time.Now(); time.Since(start) < d; {
+	}
+}
+
+func BenchmarkThroughput(b *testing.B) {
+	for _, numP := range []int{1, 2, 4, 8} {
+		for _, work := range []time.Duration{0, 50 * time.Microsecond} {
+			b.Run(fmt.Sprintf("P=%d/work=%v", numP, work), func(b *testing.B) {
+				s, _ := NewScheduler(numP, numP, WithLogger(nil))
+				s.EventDriven = true
+				s.Run()
+				defer s.Stop()
+				var last BenchResult
+				for b.Loop() {
+					last = s.Benchmark(200, func() { spin(work) })
+				}
+				b.ReportMetric(last.GsPerSec, "Gs/s")
+				util := 0.0
+				for _, u := range last.PUtilization {
+					util += u
+				}
+				b.ReportMetric(util/float64(numP), "%P-busy")
+			})
+		}
+	}
+}
+
+func TestBenchmarkResult(t *testing.T) {
+	s, _ := NewScheduler(2, 2, WithLogger(nil))
+	s.EventDriven = true
+	s.Run()
+	defer s.Stop()
+	r := s.Benchmark(50, func() { spin(100 * time.Microsecond) })
+	if r.NumP != 2 || r.NumM != 2 || r.NumG != 50 {
+		t.Fatalf("result = %+v", r)
+	}
+	if r.Elapsed <= 0 || r.GsPerSec <= 0 {
+		t.Fatalf("Elapsed = %v, GsPerSec = %v", r.Elapsed, r.GsPerSec)
+	}
+	if len(r.PUtilization) != 2 {
+		t.Fatalf("PUtilization = %v", r.PUtilization)
+	}
+	total := 0.0
+	for _, u := range r.PUtilization {
+		if u < 0 || u > 100 {
+			t.Fatalf("PUtilization = %v", r.PUtilization)
+		}
+		total += u
+	}
+	if total == 0 {
+		t.Fatal("no P was busy")
+	}
+	if st := s.Stats(); st.Completed != 50 {
+		t.Fatalf("Completed = %d, want 50", st.Completed)
+	}
+}
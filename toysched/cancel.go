@@ -0,0 +1,59 @@
+package toysched
+
+// Cancel withdraws a G that is queued but has not started yet: it is
+// removed from its run queue (or globalQ), marked Cancelled, and its
+// Func never runs. It reports whether the G was cancelled; a G that is
+// already running, has run, or isn't queued is left alone. Children
+// of g (see Spawn) that haven't started are cancelled either way, so
+// cancelling a running parent still stops the rest of its tree.
+func (g *G) Cancel() bool {
+	s := g.sched
+	s.mu.Lock()
+	ok := !g.started.Load() && g.Status() == Runnable && s.withdrawLocked(g)
+	if ok {
+		g.setStatus(Cancelled)
+		s.cancelled++
+		s.idle.Broadcast()
+	}
+	children := g.children
+	s.mu.Unlock()
+	// Outside the lock: OnDone callbacks may call into the scheduler.
+	if ok {
+		g.finish()
+	}
+	for _, c := range children {
+		c.Cancel()
+	}
+	return ok
+}
+
+// withdrawLocked takes g out of whichever run queue or globalQ holds
+// it, reporting whether it was queued. Caller must hold s.mu.
+func (s *Scheduler) withdrawLocked(g *G) bool {
+	for _, p := range s.queuesLocked() {
+		if p.remove(g) {
+			return true
+		}
+	}
+	for i, qg := range s.globalQ {
+		if qg == g {
+			s.globalQ = append(s.globalQ[:i], s.globalQ[i+1:]...)
+			s.space.Broadcast()
+			return true
+		}
+	}
+	return false
+}
+
+// remove takes g out of the run queue, reporting whether it was there.
+// Caller must hold s.mu.
+func (p *P) remove(g *G) bool {
+	for i, qg := range p.RunQ {
+		if qg == g {
+			p.RunQ = append(p.RunQ[:i], p.RunQ[i+1:]...)
+			p.NumG--
+			return true
+		}
+	}
+	return false
+}
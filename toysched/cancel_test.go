@@ -0,0 +1,122 @@
+package toysched
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCancel(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(s *Scheduler, g *G) // queue g and advance the scheduler
+		want       bool
+		wantStatus GStatus
+	}{
+		{"queued on a P", func(s *Scheduler, g *G) {
+			s.Enqueue(s.Ps[0], g)
+		}, true, Cancelled},
+		{"queued on globalQ", func(s *Scheduler, g *G) {
+			fillP(s, s.Ps[0], overflowThreshold+1)
+			s.Enqueue(s.Ps[0], g)
+		}, true, Cancelled},
+		{"never queued", func(*Scheduler, *G) {}, false, Runnable},
+		{"already done", func(s *Scheduler, g *G) {
+			s.Enqueue(s.Ps[0], g)
+			s.Step()
+		}, false, Done},
+		{"started, then yielded", func(s *Scheduler, g *G) {
+			g.Func = func() { s.Yield() }
+			s.Enqueue(s.Ps[0], g)
+			s.Step()
+		}, false, Runnable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, 1, 1)
+			ran := false
+			g := s.NewG(func() { ran = true }, false)
+			tt.setup(s, g)
+			ranBefore := ran
+
+			if got := g.Cancel(); got != tt.want {
+				t.Fatalf("Cancel() = %v, want %v", got, tt.want)
+			}
+			if got := g.Status(); got != tt.wantStatus {
+				t.Fatalf("status = %v, want %v", got, tt.wantStatus)
+			}
+			if err := s.Check(); err != nil {
+				t.Fatal(err)
+			}
+			s.RunDeterministic()
+			if tt.want && ran {
+				t.Fatal("cancelled G's function ran")
+			}
+			if tt.want && ranBefore {
+				t.Fatal("G ran before it was cancelled")
+			}
+			if tt.want && s.Stats().Cancelled != 1 {
+				t.Fatalf("Cancelled = %d, want 1", s.Stats().Cancelled)
+			}
+		})
+	}
+}
+
+func TestCancelKeepsQueueCounts(t *testing.T) {
+	s := newDeterministic(t, 1, 1)
+	var gs []*G
+	for range 4 {
+		g := s.NewG(func() {}, false)
+		s.Enqueue(s.Ps[0], g)
+		gs = append(gs, g)
+	}
+	gs[1].Cancel()
+	gs[3].Cancel()
+	if p := s.Ps[0]; p.NumG != 2 || len(p.RunQ) != 2 {
+		t.Fatalf("NumG = %d, len(RunQ) = %d; want 2", p.NumG, len(p.RunQ))
+	}
+	s.RunDeterministic()
+	if st := s.Stats(); st.Completed != 2 || st.Cancelled != 2 {
+		t.Fatalf("Completed = %d, Cancelled = %d", st.Completed, st.Cancelled)
+	}
+	gs[1].Wait() // closed on Cancel, so this returns
+}
+
+func TestCancelWhileRunning(t *testing.T) {
+	s, _ := NewScheduler(4, 4, WithLogger(nil))
+	s.TickInterval = time.Millisecond
+	s.ParkCooldown = time.Millisecond
+	s.Run()
+	defer s.Stop()
+
+	// Race Cancel against the Ms starting the same Gs.
+	ch := make(chan *G, 16)
+	done := make(chan int)
+	go func() {
+		cancelled := 0
+		for g := range ch {
+			if g.Cancel() {
+				cancelled++
+			}
+		}
+		done <- cancelled
+	}()
+	const n = 400
+	for range n {
+		g, err := s.Submit(func() {})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ch <- g
+	}
+	close(ch)
+	cancelled := <-done
+	if err := s.WaitIdle(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	st := s.Stats()
+	if int(st.Cancelled) != cancelled || int(st.Completed)+cancelled != n {
+		t.Fatalf("Completed = %d, Cancelled = %d, want %d cancelled of %d",
+			st.Completed, st.Cancelled, cancelled, n)
+	}
+}
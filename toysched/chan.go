@@ -0,0 +1,60 @@
+package toysched
+
+// SchedChan is an unbuffered channel modelled inside the scheduler. A G
+// that would block on it is parked in the channel's waiter list and its
+// M and P move on to other work; the matching operation from another G
+// hands over the value and requeues the parked G on a P.
+type SchedChan struct {
+	s *Scheduler
+	// Parked senders and receivers, oldest first. Guarded by s.mu.
+	sendq []*chanWaiter
+	recvq []*chanWaiter
+}
+
+// A G parked on a SchedChan, with the value being handed over.
+type chanWaiter struct {
+	g   *G
+	val any
+}
+
+// NewChan creates an unbuffered SchedChan for Gs of this scheduler.
+func (s *Scheduler) NewChan() *SchedChan {
+	return &SchedChan{s: s}
+}
+
+// Send hands v to a waiting receiver, or parks the calling G until one
+// arrives. It must be called from inside a running G.
+func (c *SchedChan) Send(v any) {
+	s := c.s
+	g := s.mustCurrent("Send")
+	s.gopark(g, func() bool {
+		if len(c.recvq) > 0 {
+			r := c.recvq[0]
+			c.recvq = c.recvq[1:]
+			r.val = v
+			s.readyLocked(r.g)
+			return false
+		}
+		c.sendq = append(c.sendq, &chanWaiter{g: g, val: v})
+		return true
+	})
+}
+
+// Recv takes a value from a waiting sender, or parks the calling G until
+// one arrives. It must be called from inside a running G.
+func (c *SchedChan) Recv() any {
+	s := c.s
+	w := &chanWaiter{g: s.mustCurrent("Recv")}
+	s.gopark(w.g, func() bool {
+		if len(c.sendq) > 0 {
+			sw := c.sendq[0]
+			c.sendq = c.sendq[1:]
+			w.val = sw.val
+			s.readyLocked(sw.g)
+			return false
+		}
+		c.recvq = append(c.recvq, w)
+		return true
+	})
+	return w.val
+}
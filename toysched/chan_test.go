@@ -0,0 +1,97 @@
+package toysched
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSchedChanPingPong(t *testing.T) {
+	tests := []struct {
+		name          string
+		numP, numM, n int
+		deterministic bool
+	}{
+		{"one P, stepped", 1, 1, 10, true},
+		{"two Ps, stepped", 2, 2, 10, true},
+		{"two Ps, running", 2, 2, 100, false},
+		{"more Ms than Ps", 1, 3, 50, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s *Scheduler
+			if tt.deterministic {
+				s = newDeterministic(t, tt.numP, tt.numM)
+			} else {
+				s, _ = NewScheduler(tt.numP, tt.numM, WithLogger(nil))
+				s.TickInterval = time.Millisecond
+			}
+			ping, pong := s.NewChan(), s.NewChan()
+			var got []int
+			a := s.NewG(func() {
+				for i := range tt.n {
+					ping.Send(i)
+					got = append(got, pong.Recv().(int))
+				}
+			}, false)
+			b := s.NewG(func() {
+				for range tt.n {
+					pong.Send(ping.Recv().(int) * 2)
+				}
+			}, false)
+			s.Enqueue(s.Ps[0], a)
+			s.Enqueue(s.Ps[len(s.Ps)-1], b)
+
+			if tt.deterministic {
+				s.RunDeterministic()
+			} else {
+				s.Run()
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				if err := s.WaitIdle(ctx); err != nil {
+					t.Fatal(err)
+				}
+				s.Stop()
+			}
+
+			if a.Status() != Done || b.Status() != Done {
+				t.Fatalf("Gs are %v and %v, want both Done", a.Status(), b.Status())
+			}
+			if len(got) != tt.n {
+				t.Fatalf("got %d replies, want %d", len(got), tt.n)
+			}
+			for i, v := range got {
+				if v != 2*i {
+					t.Fatalf("reply %d = %d, want %d", i, v, 2*i)
+				}
+			}
+		})
+	}
+}
+
+func TestSchedChanParksWithoutHoldingM(t *testing.T) {
+	s := newDeterministic(t, 1, 1)
+	c := s.NewChan()
+	recv := s.NewG(func() { c.Recv() }, false)
+	other := s.NewG(func() {}, false)
+	s.Enqueue(s.Ps[0], recv)
+	s.Enqueue(s.Ps[0], other)
+
+	s.Step() // recv parks
+	if recv.Status() != Waiting {
+		t.Fatalf("receiver is %v, want Waiting", recv.Status())
+	}
+	s.Step() // the only M is free to run other
+	if other.Status() != Done {
+		t.Fatalf("other G is %v, want Done", other.Status())
+	}
+	if s.Step() {
+		t.Fatal("a parked G with no sender counts as work")
+	}
+
+	s.Enqueue(s.Ps[0], s.NewG(func() { c.Send(1) }, false))
+	s.RunDeterministic()
+	if recv.Status() != Done {
+		t.Fatalf("receiver is %v after a send, want Done", recv.Status())
+	}
+}
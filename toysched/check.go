@@ -0,0 +1,80 @@
+package toysched
+
+import "fmt"
+
+// Check verifies the scheduler's internal bookkeeping and returns an
+// error describing the first inconsistency found: a P whose NumG doesn't
+// match its queue, a negative count, a G queued (or held by an M) in two
+// places at once, Ps lost or duplicated between availPs and the Ms, or
+// a blocked G missing from the blocked-G registry. These are the
+// accounting assumptions the step demos' bugs broke; call Check after
+// operations in tests to catch corruption where it happens. It holds the
+// scheduler lock while it walks every queue, so calling it in a tight
+// loop while the scheduler runs will starve the Ms.
+func (s *Scheduler) Check() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.checkLocked()
+}
+
+// checkLocked is Check. Caller must hold s.mu.
+func (s *Scheduler) checkLocked() error {
+	if s.running < 0 {
+		return fmt.Errorf("toysched: running count is %d", s.running)
+	}
+
+	seen := make(map[*G]string, len(s.globalQ)+len(s.blockedGs))
+	place := func(g *G, where string) error {
+		if prev, ok := seen[g]; ok {
+			return fmt.Errorf("toysched: G%d is in both %s and %s", g.ID, prev, where)
+		}
+		seen[g] = where
+		return nil
+	}
+	for _, g := range s.globalQ {
+		if err := place(g, "globalQ"); err != nil {
+			return err
+		}
+	}
+	ps := s.queuesLocked()
+	for _, p := range ps {
+		if p.NumG < 0 {
+			return fmt.Errorf("toysched: P%d has NumG %d", p.ID, p.NumG)
+		}
+		if p.NumG != len(p.RunQ) {
+			return fmt.Errorf("toysched: P%d has NumG %d but %d Gs queued", p.ID, p.NumG, len(p.RunQ))
+		}
+		where := fmt.Sprintf("P%d", p.ID)
+		for _, g := range p.RunQ {
+			if err := place(g, where); err != nil {
+				return err
+			}
+		}
+	}
+
+	bound := 0
+	for _, m := range s.Ms {
+		if m.P != nil && !m.P.retired {
+			bound++
+		}
+		if m.G == nil {
+			continue
+		}
+		if err := place(m.G, fmt.Sprintf("M%d", m.ID)); err != nil {
+			return err
+		}
+		// Set together with the registry entry, under s.mu.
+		if m.State() == MSyscall && s.blockedGs[m.G.ID] != m.G {
+			return fmt.Errorf("toysched: G%d is blocked on M%d but not registered", m.G.ID, m.ID)
+		}
+	}
+	if n := bound + len(s.availPs); n != len(ps) {
+		return fmt.Errorf("toysched: %d Ps held or available, want %d", n, len(ps))
+	}
+	for id, g := range s.blockedGs {
+		if where := seen[g]; where == "" || where[0] != 'M' {
+			return fmt.Errorf("toysched: registered blocked G%d is not held by an M", id)
+		}
+	}
+	return nil
+}
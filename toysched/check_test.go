@@ -0,0 +1,48 @@
+package toysched
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckReportsCorruption(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(s *Scheduler)
+		want    string // in the error; "" for none
+	}{
+		{"consistent", func(*Scheduler) {}, ""},
+		{"NumG off by one", func(s *Scheduler) { s.Ps[0].NumG++ }, "P0 has NumG 3 but 2 Gs queued"},
+		{"negative NumG", func(s *Scheduler) {
+			s.Ps[1].NumG = -1
+		}, "P1 has NumG -1"},
+		{"negative running", func(s *Scheduler) { s.running = -1 }, "running count is -1"},
+		{"G in two queues", func(s *Scheduler) {
+			s.Ps[1].push(s.Ps[0].RunQ[0])
+		}, "is in both P0 and P1"},
+		{"G on a P and globalQ", func(s *Scheduler) {
+			s.globalQ = append(s.globalQ, s.Ps[0].RunQ[1])
+		}, "is in both globalQ and P0"},
+		{"P lost", func(s *Scheduler) { <-s.availPs }, "1 Ps held or available, want 2"},
+		{"P duplicated", func(s *Scheduler) { s.availPs <- s.Ms[0].P }, "3 Ps held or available, want 2"},
+		{"unheld blocked G", func(s *Scheduler) {
+			g := s.Ps[0].RunQ[0]
+			s.blockedGs[g.ID] = g
+		}, "registered blocked G"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Two Ps, one held by M0, with two Gs queued on P0.
+			s := newDeterministic(t, 2, 1)
+			fillP(s, s.Ps[0], 2)
+			tt.corrupt(s)
+			err := s.Check()
+			switch {
+			case tt.want == "" && err != nil:
+				t.Fatalf("Check() = %v, want nil", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Fatalf("Check() = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// Command bench runs the same batch of small Gs on 1, 2, 4 and 8 Ps and
+// prints the throughput, showing how far the toy scheduler scales before
+// its single lock becomes the bottleneck. It then repeats the sweep in
+// Centralized mode, where every M fights over the one global queue.
+// With -cpuprofile it also writes a CPU profile of the 8-P per-P run.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"toysched"
+)
+
+var cpuprofile = flag.String("cpuprofile", "", "write a CPU profile of the 8-P run to `file`")
+
+func main() {
+	flag.Parse()
+	const numG = 20_000
+	work := func() {
+		// About a microsecond of CPU.
+		x := 0
+		for i := range 1000 {
+			x += i
+		}
+		_ = x
+	}
+
+	for _, centralized := range []bool{false, true} {
+		if centralized {
+			fmt.Println("Centralized:")
+		} else {
+			fmt.Println("Per-P queues:")
+		}
+		for _, n := range []int{1, 2, 4, 8} {
+			sweep(n, centralized, numG, work)
+		}
+	}
+}
+
+// sweep benchmarks numG Gs running work on n Ps and n Ms.
+func sweep(n int, centralized bool, numG int, work func()) {
+	sched, err := toysched.NewScheduler(n, n)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sched.SetConsole(nil)
+	sched.EventDriven = true
+	sched.Centralized = centralized
+	sched.Run()
+	var r toysched.BenchResult
+	if *cpuprofile != "" && n == 8 && !centralized {
+		err = sched.RunWithProfile(*cpuprofile, func(s *toysched.Scheduler) {
+			r = s.Benchmark(numG, work)
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		r = sched.Benchmark(numG, work)
+	}
+	sched.Stop()
+
+	util := make([]string, len(r.PUtilization))
+	for i, u := range r.PUtilization {
+		util[i] = fmt.Sprintf("%.0f%%", u)
+	}
+	fmt.Printf("  %d Ps: %d Gs in %v, %.0f Gs/sec, P busy [%s]\n",
+		r.NumP, r.NumG, r.Elapsed.Round(time.Millisecond), r.GsPerSec, strings.Join(util, " "))
+}
@@ -0,0 +1,37 @@
+// Command deterministic replays the toysched demo one logical tick at a
+// time on a single goroutine, so its output is identical on every run.
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"toysched"
+)
+
+func main() {
+	// 2 Ps, 2 Ms.
+	sched, err := toysched.NewScheduler(2, 2, toysched.WithDeterministic())
+	if err != nil {
+		log.Fatal(err)
+	}
+	p0, p1 := sched.Ps[0], sched.Ps[1]
+
+	work := func(name string) func() {
+		return func() {
+			fmt.Printf("  %s doing some work...\n", name)
+			for i := 0; i < 3; i++ {
+				fmt.Printf("    Work step %d\n", i+1)
+			}
+		}
+	}
+
+	sched.Enqueue(p0, sched.NewG(work("G"), false))
+	sched.Enqueue(p1, sched.NewG(work("G2"), false))
+	// Blocks for 300ms of virtual time after its work.
+	sched.Enqueue(p0, sched.NewGBlocking(work("G3"), 300*time.Millisecond))
+
+	sched.RunDeterministic()
+	fmt.Println("=== Schedule Complete ===")
+}
@@ -0,0 +1,46 @@
+// Command sleep shows SleepG freeing the only P: while G0 sleeps for
+// 500ms, the same P runs the other queued Gs instead of idling.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"toysched"
+)
+
+func main() {
+	// 1 P, 1 M: a blocking time.Sleep would stall everything.
+	sched, err := toysched.NewScheduler(1, 1)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sched.EventDriven = true
+
+	start := time.Now()
+	elapsed := func() time.Duration {
+		return time.Since(start).Round(10 * time.Millisecond)
+	}
+
+	p0 := sched.Ps[0]
+	sched.Enqueue(p0, sched.NewG(func() {
+		fmt.Printf("    G0 going to sleep at %v\n", elapsed())
+		sched.SleepG(500 * time.Millisecond)
+		fmt.Printf("    G0 woke up at %v\n", elapsed())
+	}, false))
+	for i := 1; i <= 3; i++ {
+		sched.Enqueue(p0, sched.NewG(func() {
+			time.Sleep(50 * time.Millisecond)
+			fmt.Printf("    G%d done at %v\n", i, elapsed())
+		}, false))
+	}
+
+	sched.Run()
+	if err := sched.WaitIdle(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	sched.Stop()
+	fmt.Println("=== Schedule Complete ===")
+}
@@ -0,0 +1,63 @@
+// Command toysched runs the step7 demo on top of the toysched package.
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"toysched"
+)
+
+func main() {
+	// 2 Ps, 2 Ms.
+	sched, err := toysched.NewScheduler(2, 2)
+	if err != nil {
+		log.Fatal(err)
+	}
+	p0, p1 := sched.Ps[0], sched.Ps[1]
+	// Slower tick to reduce spam.
+	sched.TickInterval = 100 * time.Millisecond
+
+	sampleWork := func() {
+		fmt.Println("  G doing some work...")
+		for i := 0; i < 3; i++ {
+			time.Sleep(100 * time.Millisecond)
+			fmt.Printf("    Work step %d\n", i+1)
+		}
+	}
+
+	sampleWork2 := func() {
+		fmt.Println("  G2 doing some work...")
+		for i := 0; i < 3; i++ {
+			time.Sleep(100 * time.Millisecond)
+			fmt.Printf("    Work step %d\n", i+1)
+		}
+	}
+
+	sampleWork3 := func() {
+		fmt.Println("  G3 doing some work...")
+		for i := 0; i < 3; i++ {
+			time.Sleep(100 * time.Millisecond)
+			fmt.Printf("    Work step %d\n", i+1)
+		}
+		// After work, "block" on channel (simulates syscall after compute).
+		fmt.Println("  G3: Entering block... (syscall sim)...")
+	}
+
+	g0 := sched.NewG(sampleWork, false)
+	g1 := sched.NewG(sampleWork2, false)
+	// Blocks for 300ms after its work, then unblocks itself.
+	g2 := sched.NewGBlocking(sampleWork3, 300*time.Millisecond)
+
+	sched.Enqueue(p0, g0)
+	sched.Enqueue(p1, g1)
+	sched.Enqueue(p0, g2)
+
+	sched.Run()
+
+	// Let the Ms work for a bit.
+	time.Sleep(3 * time.Second)
+	sched.Stop()
+	fmt.Println("=== Schedule Complete ===")
+}
@@ -0,0 +1,37 @@
+// Command yield shows two cooperative Gs sharing one M by calling Yield
+// between work steps, so their output interleaves.
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"toysched"
+)
+
+func main() {
+	// 1 P, 1 M: only one G can run at a time.
+	sched, err := toysched.NewScheduler(1, 1)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	work := func(name string) func() {
+		return func() {
+			for i := 0; i < 3; i++ {
+				time.Sleep(100 * time.Millisecond)
+				fmt.Printf("    %s work step %d\n", name, i+1)
+				// Let the other G have a turn.
+				sched.Yield()
+			}
+		}
+	}
+
+	p0 := sched.Ps[0]
+	sched.Enqueue(p0, sched.NewG(work("G0"), false))
+	sched.Enqueue(p0, sched.NewG(work("G1"), false))
+
+	sched.RunAll()
+	fmt.Println("=== Schedule Complete ===")
+}
@@ -0,0 +1,51 @@
+package toysched
+
+import (
+	"context"
+	"fmt"
+)
+
+// Collect waits for every G in gs to finish and returns their outcomes
+// in the same order, so a batch of NewGErr Gs can be submitted and then
+// gathered map-reduce style. An entry is nil for a G that is Done, the
+// G's Err for one that Failed (what its function returned, or a
+// *PanicError), and a non-nil error for one that was Cancelled.
+func (s *Scheduler) Collect(gs []*G) []error {
+	errs, _ := s.CollectContext(context.Background(), gs)
+	return errs
+}
+
+// CollectContext is like Collect but gives up when ctx is done, in
+// which case it also returns ctx.Err(), and the entry for each G that
+// had not finished is ctx.Err() too.
+func (s *Scheduler) CollectContext(ctx context.Context, gs []*G) ([]error, error) {
+	errs := make([]error, len(gs))
+	var ctxErr error
+	for i, g := range gs {
+		if ctxErr == nil {
+			ctxErr = g.WaitContext(ctx)
+		}
+		if ctxErr != nil {
+			select {
+			case <-g.done:
+			default:
+				errs[i] = ctxErr
+				continue
+			}
+		}
+		errs[i] = g.outcome()
+	}
+	return errs, ctxErr
+}
+
+// outcome is the error Collect reports for a finished G.
+func (g *G) outcome() error {
+	switch st := g.Status(); {
+	case st == Done:
+		return nil
+	case g.Err != nil:
+		return g.Err
+	default:
+		return fmt.Errorf("toysched: G%d is %v", g.ID, st)
+	}
+}
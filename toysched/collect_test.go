@@ -0,0 +1,93 @@
+package toysched
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCollectKeepsInputOrder(t *testing.T) {
+	s, _ := NewScheduler(4, 4, WithLogger(nil))
+	s.TickInterval = time.Millisecond
+	s.Run()
+	defer s.Stop()
+
+	const n = 20
+	var gs []*G
+	for i := range n {
+		g, err := s.SubmitErr(func() error {
+			// Later Gs finish first.
+			time.Sleep(time.Duration(n-i) * 100 * time.Microsecond)
+			switch {
+			case i == 7:
+				panic("boom")
+			case i%3 == 0:
+				return fmt.Errorf("task %d", i)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		gs = append(gs, g)
+	}
+	errs := s.Collect(gs)
+	if len(errs) != n {
+		t.Fatalf("%d results, want %d", len(errs), n)
+	}
+	for i, err := range errs {
+		var pe *PanicError
+		switch {
+		case i == 7:
+			if !errors.As(err, &pe) || pe.Value != "boom" {
+				t.Errorf("result %d = %v, want the panic", i, err)
+			}
+		case i%3 == 0:
+			if err == nil || err.Error() != fmt.Sprintf("task %d", i) {
+				t.Errorf("result %d = %v, want task %d's error", i, err, i)
+			}
+		default:
+			if err != nil {
+				t.Errorf("result %d = %v, want nil", i, err)
+			}
+		}
+	}
+}
+
+func TestCollectCancelled(t *testing.T) {
+	s := newDeterministic(t, 1, 1)
+	ok, cancelled := s.NewGErr(func() error { return nil }), s.NewG(func() {}, false)
+	s.Enqueue(s.Ps[0], ok)
+	s.Enqueue(s.Ps[0], cancelled)
+	cancelled.Cancel()
+	s.RunDeterministic()
+	errs := s.Collect([]*G{ok, cancelled})
+	if errs[0] != nil || errs[1] == nil {
+		t.Fatalf("Collect = %v, want [nil, an error for the cancelled G]", errs)
+	}
+}
+
+func TestCollectContextTimesOut(t *testing.T) {
+	s, _ := NewScheduler(2, 2, WithLogger(nil))
+	s.TickInterval = time.Millisecond
+	s.Run()
+	defer s.Stop()
+
+	release := make(chan struct{})
+	defer close(release)
+	quick, _ := s.SubmitErr(func() error { return nil })
+	stuck, _ := s.Submit(func() { <-release })
+	quick.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	errs, err := s.CollectContext(ctx, []*G{stuck, quick})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want DeadlineExceeded", err)
+	}
+	if !errors.Is(errs[0], context.DeadlineExceeded) || errs[1] != nil {
+		t.Fatalf("results = %v, want [DeadlineExceeded, nil]", errs)
+	}
+}
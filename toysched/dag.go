@@ -0,0 +1,62 @@
+package toysched
+
+import "fmt"
+
+// SubmitAfter returns a G for f that is queued, as by Submit, only once
+// dep is Done. If dep fails or is cancelled instead, the new G is
+// Cancelled without running, with Err saying why. A G may have any
+// number of dependents.
+func (s *Scheduler) SubmitAfter(dep *G, f func()) *G {
+	return s.SubmitAfterAll([]*G{dep}, f)
+}
+
+// SubmitAfterAll is SubmitAfter for several dependencies: the new G is
+// queued once every one of deps is Done, which is enough to build simple
+// DAGs. With no deps it is queued straight away.
+func (s *Scheduler) SubmitAfterAll(deps []*G, f func()) *G {
+	g := s.NewG(f, false)
+	if len(deps) == 0 {
+		s.startAfter(g, nil)
+		return g
+	}
+
+	// Guarded by s.mu.
+	remaining := len(deps)
+	var depErr error
+	for _, dep := range deps {
+		dep.OnDone(func(dep *G) {
+			s.mu.Lock()
+			remaining--
+			if st := dep.Status(); st != Done && depErr == nil {
+				depErr = fmt.Errorf("toysched: dependency G%d is %v", dep.ID, st)
+			}
+			last, err := remaining == 0, depErr
+			s.mu.Unlock()
+			if last {
+				s.startAfter(g, err)
+			}
+		})
+	}
+	return g
+}
+
+// startAfter queues g now that its dependencies have finished, or, if
+// one of them failed (err) or g can't be queued, cancels it.
+func (s *Scheduler) startAfter(g *G, err error) {
+	s.mu.Lock()
+	if err == nil && s.shutdown {
+		err = ErrShutdown
+	}
+	if err == nil {
+		if err = s.placeLocked(g); err == nil {
+			s.mu.Unlock()
+			return
+		}
+	}
+	g.Err = err
+	g.setStatus(Cancelled)
+	s.cancelled++
+	s.idle.Broadcast()
+	s.mu.Unlock()
+	g.finish()
+}
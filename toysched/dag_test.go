@@ -0,0 +1,72 @@
+package toysched
+
+import "testing"
+
+func TestSubmitAfterDiamond(t *testing.T) {
+	tests := []struct {
+		name   string
+		yieldB int // B yields this many times, so B and C finish in either order
+		yieldC int
+	}{
+		{"B finishes first", 0, 3},
+		{"C finishes first", 3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, 2, 2)
+			var order []string
+			work := func(name string, yields int) func() {
+				return func() {
+					for range yields {
+						s.Yield()
+					}
+					order = append(order, name)
+				}
+			}
+			a := s.NewG(work("A", 0), false)
+			b := s.SubmitAfter(a, work("B", tt.yieldB))
+			c := s.SubmitAfter(a, work("C", tt.yieldC))
+			d := s.SubmitAfterAll([]*G{b, c}, work("D", 0))
+			if st := d.Status(); st != Runnable || len(s.QueuedGs(0))+len(s.QueuedGs(1)) != 0 {
+				t.Fatal("dependents were queued before A ran")
+			}
+			s.Enqueue(s.Ps[0], a)
+			s.RunDeterministic()
+
+			if len(order) != 4 || order[0] != "A" || order[3] != "D" {
+				t.Fatalf("order = %q, want A first and D last", order)
+			}
+			// Event order, as the virtual clock has only tick resolution.
+			at := make(map[[2]int]int)
+			for i, e := range s.DumpTrace() {
+				at[[2]int{int(e.Kind), e.GID}] = i
+			}
+			dStart := at[[2]int{int(EventStart), d.ID}]
+			if dStart < at[[2]int{int(EventFinish), b.ID}] || dStart < at[[2]int{int(EventFinish), c.ID}] {
+				t.Fatal("D started before B and C finished")
+			}
+		})
+	}
+}
+
+func TestSubmitAfterFailedDependency(t *testing.T) {
+	s := newDeterministic(t, 1, 1)
+	ok := s.NewG(func() {}, false)
+	bad := s.NewG(func() { panic("boom") }, false)
+	ran := false
+	after := s.SubmitAfterAll([]*G{ok, bad}, func() { ran = true })
+	none := s.SubmitAfterAll(nil, func() {})
+	s.Enqueue(s.Ps[0], ok)
+	s.Enqueue(s.Ps[0], bad)
+	s.RunDeterministic()
+
+	if ran || after.Status() != Cancelled || after.Err == nil {
+		t.Fatalf("dependent of a failed G: ran %v, status %v, Err %v", ran, after.Status(), after.Err)
+	}
+	if none.Status() != Done {
+		t.Fatalf("G with no dependencies is %v, want Done", none.Status())
+	}
+	if got := s.Stats(); got.Cancelled != 1 || got.Failed != 1 {
+		t.Fatalf("Stats: %d cancelled, %d failed", got.Cancelled, got.Failed)
+	}
+}
@@ -0,0 +1,101 @@
+package toysched
+
+import (
+	"fmt"
+	"time"
+)
+
+// defaultDeadlockTicks is how many ticks in a row every M must be parked
+// with work pending before the watchdog reports a deadlock.
+const defaultDeadlockTicks = 50
+
+// OnDeadlock registers f to be called (on the watchdog goroutine) when
+// every M has been parked for DeadlockTicks ticks in a row while Gs are
+// still queued, a state in which nothing would ever run them. f gets the
+// number of pending Gs. Registering a hook turns the watchdog on; see
+// DeadlockTicks for running it without one.
+func (s *Scheduler) OnDeadlock(f func(pending int)) {
+	s.onDeadlock = f
+}
+
+// startWatchdog checks for a deadlock, and for a fully parked pool (see
+// AllParked), once per TickInterval. Run launches it when DeadlockTicks
+// or OnDeadlock is set, or AllParked has been called.
+func (s *Scheduler) startWatchdog() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.TickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		s.checkDeadlock()
+	}
+}
+
+// checkDeadlock counts one tick towards a deadlock if every M is parked
+// with Gs still queued, and reports the deadlock once the count reaches
+// DeadlockTicks: via the OnDeadlock hook, or by panicking with the queue
+// depths if there is none.
+func (s *Scheduler) checkDeadlock() {
+	s.mu.Lock()
+	pending := len(s.globalQ)
+	depths := make([]int, 0, len(s.Ps))
+	for _, p := range s.Ps {
+		pending += p.NumG
+		depths = append(depths, p.NumG)
+	}
+	allParked := len(s.Ms) > 0
+	for _, m := range s.Ms {
+		// The system M idles holding its P; it can't take user Gs.
+		if m.P != nil && m.P == s.sysP {
+			continue
+		}
+		if m.State() != MParked {
+			allParked = false
+		}
+	}
+	s.noteParkedLocked(allParked && pending == 0 && s.resumed == nil)
+	if s.DeadlockTicks <= 0 && s.onDeadlock == nil {
+		s.mu.Unlock()
+		return
+	}
+	// A polling M in its park cooldown will still grab a free P later.
+	if !s.EventDriven && len(s.availPs) > 0 {
+		allParked = false
+	}
+	// Paused Ms are held back on purpose, not stuck.
+	if s.resumed != nil {
+		allParked = false
+	}
+	if !allParked || pending == 0 {
+		s.stuckTicks = 0
+		s.mu.Unlock()
+		return
+	}
+	s.stuckTicks++
+	limit := s.DeadlockTicks
+	if limit <= 0 {
+		limit = defaultDeadlockTicks
+	}
+	if s.stuckTicks < limit {
+		s.mu.Unlock()
+		return
+	}
+	s.stuckTicks = 0
+	globalQ := len(s.globalQ)
+	onDeadlock := s.onDeadlock
+	s.mu.Unlock()
+
+	if onDeadlock != nil {
+		onDeadlock(pending)
+		return
+	}
+	s.printTrace()
+	panic(fmt.Sprintf("toysched: deadlock: all %d Ms parked for %d ticks with %d Gs pending (P queue depths %v, globalQ %d)",
+		len(s.Ms), limit, pending, depths, globalQ))
+}
@@ -0,0 +1,57 @@
+package toysched
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeadlockWatchdog(t *testing.T) {
+	tests := []struct {
+		name     string
+		hook     bool
+		loseP    bool // take the parked P out of availPs so no M can grab it
+		wantFire bool
+	}{
+		{"lost P, hook", true, true, true},
+		{"lost P, panic", false, true, true},
+		{"P still available", true, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, 1, 1)
+			s.DeadlockTicks = 3
+			s.Step() // nothing to run: M0 parks P0
+			if s.Ms[0].State() != MParked {
+				t.Fatalf("M0 is %v, want parked", s.Ms[0].State())
+			}
+			if tt.loseP {
+				<-s.availPs
+			}
+			s.Ps[0].push(s.NewG(func() {}, false))
+
+			fired := -1
+			if tt.hook {
+				s.OnDeadlock(func(pending int) { fired = pending })
+			}
+			var msg string
+			func() {
+				defer func() {
+					msg, _ = recover().(string)
+				}()
+				for range 2 * s.DeadlockTicks {
+					s.Step()
+				}
+			}()
+
+			if tt.hook {
+				if got := fired == 1; got != tt.wantFire {
+					t.Fatalf("OnDeadlock got pending = %d", fired)
+				}
+				return
+			}
+			if !strings.Contains(msg, "deadlock") || !strings.Contains(msg, "1 Gs pending") {
+				t.Fatalf("panic = %q, want a deadlock report", msg)
+			}
+		})
+	}
+}
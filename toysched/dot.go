@@ -0,0 +1,64 @@
+package toysched
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ToDOT renders the current G-M-P topology as a Graphviz digraph: M→P
+// bindings, each P's queued Gs, globalQ, and the G each M is running or
+// blocked with. Parked Ms and empty Ps are drawn dashed and gray. It is
+// built from one State snapshot, so the picture is consistent; render
+// it with e.g. `dot -Tpng`.
+func (s *Scheduler) ToDOT() string {
+	st := s.State()
+	status := make(map[int]GStatus, len(st.Gs))
+	for _, g := range st.Gs {
+		status[g.ID] = g.Status
+	}
+	idle := `, style=dashed, color=gray, fontcolor=gray`
+
+	var b strings.Builder
+	b.WriteString("digraph gmp {\n\trankdir=LR;\n")
+	for _, g := range st.Gs {
+		style := ""
+		switch g.Status {
+		case Blocked, Waiting:
+			style = `, style=filled, fillcolor=lightpink`
+		case Running:
+			style = `, style=filled, fillcolor=palegreen`
+		}
+		fmt.Fprintf(&b, "\tG%d [shape=circle, label=\"G%d\\n%v\"%s];\n", g.ID, g.ID, g.Status, style)
+	}
+	for _, p := range st.Ps {
+		style := ""
+		if len(p.RunQ) == 0 {
+			style = idle
+		}
+		fmt.Fprintf(&b, "\tP%d [shape=box%s];\n", p.ID, style)
+		for i, gid := range p.RunQ {
+			fmt.Fprintf(&b, "\tP%d -> G%d [label=\"%d\"];\n", p.ID, gid, i)
+		}
+	}
+	for _, m := range st.Ms {
+		style := ""
+		if m.P < 0 && m.G < 0 {
+			style = idle
+		}
+		fmt.Fprintf(&b, "\tM%d [shape=hexagon%s];\n", m.ID, style)
+		if m.P >= 0 {
+			fmt.Fprintf(&b, "\tM%d -> P%d;\n", m.ID, m.P)
+		}
+		if m.G >= 0 {
+			fmt.Fprintf(&b, "\tM%d -> G%d [style=bold, label=\"%v\"];\n", m.ID, m.G, status[m.G])
+		}
+	}
+	if len(st.GlobalQ) > 0 {
+		b.WriteString("\tglobalQ [shape=box, style=rounded];\n")
+		for i, gid := range st.GlobalQ {
+			fmt.Fprintf(&b, "\tglobalQ -> G%d [label=\"%d\"];\n", gid, i)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
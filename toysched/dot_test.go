@@ -0,0 +1,46 @@
+package toysched
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestToDOT(t *testing.T) {
+	s := newDeterministic(t, 3, 2)
+	a, b := s.NewG(func() {}, false), s.NewG(func() {}, false)
+	s.Enqueue(s.Ps[0], a)
+	s.Enqueue(s.Ps[0], b)
+	fillP(s, s.Ps[1], overflowThreshold+1)
+	over := s.NewG(func() {}, false)
+	s.Enqueue(s.Ps[1], over) // P1 full: onto globalQ
+
+	dot := s.ToDOT()
+	if !strings.HasPrefix(dot, "digraph gmp {\n") || !strings.HasSuffix(dot, "}\n") {
+		t.Fatalf("not a digraph:\n%s", dot)
+	}
+	want := []string{
+		fmt.Sprintf("M%d -> P%d;", s.Ms[0].ID, s.Ps[0].ID),
+		fmt.Sprintf("M%d -> P%d;", s.Ms[1].ID, s.Ps[1].ID),
+		fmt.Sprintf("P%d -> G%d [label=\"0\"];", s.Ps[0].ID, a.ID),
+		fmt.Sprintf("P%d -> G%d [label=\"1\"];", s.Ps[0].ID, b.ID),
+		"globalQ [shape=box, style=rounded];",
+		fmt.Sprintf("globalQ -> G%d [label=\"0\"];", over.ID),
+		// P2 has no M and no Gs.
+		fmt.Sprintf("P%d [shape=box, style=dashed, color=gray, fontcolor=gray];", s.Ps[2].ID),
+	}
+	for _, line := range want {
+		if !strings.Contains(dot, "\t"+line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, dot)
+		}
+	}
+	if n := strings.Count(dot, "globalQ ->"); n != 1 {
+		t.Errorf("%d globalQ edges, want 1", n)
+	}
+
+	// Once everything has run, globalQ is gone.
+	s.RunDeterministic()
+	if dot := s.ToDOT(); strings.Contains(dot, "globalQ") {
+		t.Fatalf("globalQ still drawn when empty:\n%s", dot)
+	}
+}
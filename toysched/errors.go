@@ -0,0 +1,35 @@
+package toysched
+
+import "errors"
+
+// Errors returned by the scheduler's methods, for use with errors.Is.
+// Where a method adds detail it wraps one of these.
+var (
+	// ErrNoSuchP is returned by AddM for a P index past the end of Ps,
+	// and by Enqueue, EnqueueBatch and Submit for a G pinned to a P that
+	// doesn't exist.
+	ErrNoSuchP = errors.New("toysched: no such P")
+	// ErrPHeld is returned by AddM for a P that another M holds.
+	ErrPHeld = errors.New("toysched: P is held by another M")
+	// ErrSchedulerStopped is returned by Submit, Enqueue, EnqueueBatch
+	// and AddM once Stop has been called.
+	ErrSchedulerStopped = errors.New("toysched: scheduler is stopped")
+	// ErrNotBlocked is returned by Unblock for a G that is not blocked,
+	// or, from G.Unblock, one that never blocks.
+	ErrNotBlocked = errors.New("toysched: G is not blocked")
+	// ErrShutdown is returned by Submit once Shutdown has begun, and by
+	// a Submit or Enqueue that was still waiting for globalQ space.
+	ErrShutdown = errors.New("toysched: scheduler is shutting down")
+	// ErrQueueFull is returned by Submit and Enqueue when globalQ is at
+	// GlobalQueueCap, or the P is full and DisableOverflow is set, and
+	// BlockOnFull is not set.
+	ErrQueueFull = errors.New("toysched: queue is full")
+	// ErrAlreadyResumed is returned by Unblock for a G that has already
+	// been signalled.
+	ErrAlreadyResumed = errors.New("toysched: G already resumed")
+	// ErrTooManyGs is returned by Submit when MaxGs Gs are already live.
+	ErrTooManyGs = errors.New("toysched: too many live Gs")
+	// ErrNoSystemP is returned by SubmitSystem on a scheduler made
+	// without WithSystemP.
+	ErrNoSystemP = errors.New("toysched: no system P; see WithSystemP")
+)
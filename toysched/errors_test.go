@@ -0,0 +1,96 @@
+package toysched
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSentinelErrors(t *testing.T) {
+	noop := func() {}
+	tests := []struct {
+		name string
+		call func(s *Scheduler) error
+		want error
+	}{
+		{"AddM past the Ps", func(s *Scheduler) error {
+			_, err := s.AddM(9, 2)
+			return err
+		}, ErrNoSuchP},
+		{"AddM on a held P", func(s *Scheduler) error {
+			_, err := s.AddM(9, 0)
+			return err
+		}, ErrPHeld},
+		{"AddM after Stop", func(s *Scheduler) error {
+			s.Stop()
+			_, err := s.AddM(9, -1)
+			return err
+		}, ErrSchedulerStopped},
+		{"Enqueue pinned to a missing P", func(s *Scheduler) error {
+			return s.Enqueue(s.Ps[0], s.NewGPinned(noop, 7))
+		}, ErrNoSuchP},
+		{"EnqueueBatch pinned to a missing P", func(s *Scheduler) error {
+			err := s.EnqueueBatch([]*G{s.NewG(noop, false), s.NewGPinned(noop, 7)})
+			if n := s.Ps[0].NumG + s.Ps[1].NumG; n != 0 {
+				return fmt.Errorf("%d Gs queued despite the bad pin", n)
+			}
+			return err
+		}, ErrNoSuchP},
+		{"Submit after Stop", func(s *Scheduler) error {
+			s.Stop()
+			_, err := s.Submit(noop)
+			return err
+		}, ErrSchedulerStopped},
+		{"Enqueue after Stop", func(s *Scheduler) error {
+			s.Stop()
+			return s.Enqueue(s.Ps[0], s.NewG(noop, false))
+		}, ErrSchedulerStopped},
+		{"EnqueueBatch after Stop", func(s *Scheduler) error {
+			s.Stop()
+			err := s.EnqueueBatch([]*G{s.NewG(noop, false), s.NewG(noop, false)})
+			if n := s.Ps[0].NumG + s.Ps[1].NumG; n != 0 {
+				return fmt.Errorf("%d Gs queued after Stop", n)
+			}
+			return err
+		}, ErrSchedulerStopped},
+		{"Submit after Shutdown", func(s *Scheduler) error {
+			s.Shutdown(Abort)
+			_, err := s.Submit(noop)
+			return err
+		}, ErrShutdown},
+		{"Submit past GlobalQueueCap", func(s *Scheduler) error {
+			s.Centralized = true
+			s.GlobalQueueCap = 1
+			s.Submit(noop)
+			_, err := s.Submit(noop)
+			return err
+		}, ErrQueueFull},
+		{"Submit past MaxGs", func(s *Scheduler) error {
+			s.MaxGs = 1
+			s.Submit(noop)
+			_, err := s.Submit(noop)
+			return err
+		}, ErrTooManyGs},
+		{"SubmitSystem without a system P", func(s *Scheduler) error {
+			_, err := s.SubmitSystem(noop)
+			return err
+		}, ErrNoSystemP},
+		{"Unblock an unknown G", func(s *Scheduler) error { return s.Unblock(42) }, ErrNotBlocked},
+		{"Unblock a G that never blocks", func(s *Scheduler) error {
+			return s.NewG(noop, false).Unblock()
+		}, ErrNotBlocked},
+		{"Unblock twice", func(s *Scheduler) error {
+			g := s.NewGBlocking(noop, 0)
+			g.Unblock()
+			return g.Unblock()
+		}, ErrAlreadyResumed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, 2, 1)
+			if err := tt.call(s); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
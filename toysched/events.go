@@ -0,0 +1,251 @@
+package toysched
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// EventKind identifies a scheduler transition.
+type EventKind int
+
+const (
+	// M started a G for the first time.
+	EventStart EventKind = iota
+	// M resumed a G that had yielded or been unblocked.
+	EventResume
+	// G yielded back to its P's queue.
+	EventYield
+	// G ran out of its TimeBudget and was requeued.
+	EventPreempt
+	// G parked on a scheduler primitive; its M moves on.
+	EventWait
+	// Parked G made runnable again (PID is -1 if it went to globalQ).
+	EventReady
+	// G finished normally.
+	EventFinish
+	// G panicked; the M carries on.
+	EventFail
+	// G blocked; its M handed off the P.
+	EventBlock
+	// G blocked; its M kept the P for now (sysmon on).
+	EventSyscall
+	// sysmon took a P from an M stuck in a long block.
+	EventRetake
+	// Blocked G woke up (PID is -1 if it went to globalQ).
+	EventUnblock
+	// M grabbed a P from availPs.
+	EventGrab
+	// M found no work and handed its P back.
+	EventPark
+	// M stole work (From is -1 for globalQ).
+	EventSteal
+	// Enqueue spilled a G to globalQ.
+	EventOverflow
+)
+
+var eventNames = [...]string{
+	EventStart:    "start",
+	EventResume:   "resume",
+	EventYield:    "yield",
+	EventPreempt:  "preempt",
+	EventWait:     "wait",
+	EventReady:    "ready",
+	EventFinish:   "finish",
+	EventFail:     "fail",
+	EventBlock:    "block",
+	EventSyscall:  "syscall",
+	EventRetake:   "retake",
+	EventUnblock:  "unblock",
+	EventGrab:     "grab",
+	EventPark:     "park",
+	EventSteal:    "steal",
+	EventOverflow: "overflow",
+}
+
+func (k EventKind) String() string {
+	if k >= 0 && int(k) < len(eventNames) {
+		return eventNames[k]
+	}
+	return fmt.Sprintf("EventKind(%d)", int(k))
+}
+
+// SchedEvent is one scheduler transition. IDs that don't apply are -1.
+type SchedEvent struct {
+	Kind EventKind
+	MID  int
+	PID  int
+	GID  int
+	// For EventSteal: victim P (-1 for globalQ) and number of Gs moved;
+	// GID is the first G moved.
+	// For EventOverflow, N is the Gs moved when a full P spilled half.
+	From int
+	N    int
+	Time time.Time
+}
+
+// String renders the event the way the step demos printed it.
+func (e SchedEvent) String() string {
+	switch e.Kind {
+	case EventStart:
+		return fmt.Sprintf("M%d on P%d: Starting G%d", e.MID, e.PID, e.GID)
+	case EventResume:
+		return fmt.Sprintf("M%d on P%d: Resuming G%d", e.MID, e.PID, e.GID)
+	case EventYield:
+		return fmt.Sprintf("M%d on P%d: G%d yielded", e.MID, e.PID, e.GID)
+	case EventPreempt:
+		return fmt.Sprintf("M%d on P%d: Preempted G%d (budget spent)", e.MID, e.PID, e.GID)
+	case EventWait:
+		return fmt.Sprintf("M%d on P%d: G%d waiting", e.MID, e.PID, e.GID)
+	case EventReady:
+		if e.PID < 0 {
+			return fmt.Sprintf("G%d ready on globalQ", e.GID)
+		}
+		return fmt.Sprintf("G%d ready on P%d", e.GID, e.PID)
+	case EventFinish:
+		return fmt.Sprintf("M%d on P%d: Finished G%d", e.MID, e.PID, e.GID)
+	case EventFail:
+		return fmt.Sprintf("M%d on P%d: G%d failed, carrying on", e.MID, e.PID, e.GID)
+	case EventBlock:
+		return fmt.Sprintf("M%d on P%d: G%d blocked, handing off P", e.MID, e.PID, e.GID)
+	case EventSyscall:
+		return fmt.Sprintf("M%d on P%d: G%d blocked, keeping P", e.MID, e.PID, e.GID)
+	case EventRetake:
+		return fmt.Sprintf("sysmon: Retook P%d from M%d (G%d blocked too long)", e.PID, e.MID, e.GID)
+	case EventUnblock:
+		if e.PID < 0 {
+			return fmt.Sprintf("M%d: G%d unblocked, no free P, moved to globalQ", e.MID, e.GID)
+		}
+		return fmt.Sprintf("M%d: G%d unblocked, grabbed P%d", e.MID, e.GID, e.PID)
+	case EventGrab:
+		return fmt.Sprintf("M%d: Grabbed available P%d", e.MID, e.PID)
+	case EventPark:
+		return fmt.Sprintf("M%d: Parking, handing off P%d", e.MID, e.PID)
+	case EventSteal:
+		if e.From < 0 {
+			return fmt.Sprintf("M%d: Stole G%d from global to P%d", e.MID, e.GID, e.PID)
+		}
+		return fmt.Sprintf("M%d: Stole %d Gs from P%d to P%d", e.MID, e.N, e.From, e.PID)
+	case EventOverflow:
+		if e.N > 0 {
+			return fmt.Sprintf("Overflow: P%d full, moved %d Gs (incl. G%d) to globalQ", e.PID, e.N, e.GID)
+		}
+		if e.PID < 0 {
+			return fmt.Sprintf("Overflow: Enqueued G%d to globalQ (no room on any P)", e.GID)
+		}
+		return fmt.Sprintf("Overflow: Enqueued G%d to globalQ (P%d full)", e.GID, e.PID)
+	}
+	return fmt.Sprintf("%v M%d P%d G%d", e.Kind, e.MID, e.PID, e.GID)
+}
+
+// Size of the Events buffer; events beyond it are dropped.
+const eventBuffer = 256
+
+// How many of the latest events DumpTrace keeps.
+const traceRingSize = 1000
+
+// eventRing keeps the last traceRingSize events whether or not anyone
+// reads Events. It has its own lock because emit runs both with and
+// without s.mu held.
+type eventRing struct {
+	mu   sync.Mutex
+	buf  []SchedEvent
+	next int
+}
+
+func (r *eventRing) add(e SchedEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.buf) < traceRingSize {
+		r.buf = append(r.buf, e)
+		return
+	}
+	r.buf[r.next] = e
+	r.next = (r.next + 1) % traceRingSize
+}
+
+// DumpTrace returns up to the last 1000 events, oldest first. Unlike
+// Events it loses nothing to a slow or absent reader, so it serves as a
+// post-mortem: the deadlock watchdog and a panicking M print it to
+// stderr before going down.
+func (s *Scheduler) DumpTrace() []SchedEvent {
+	r := &s.ring
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]SchedEvent, 0, len(r.buf))
+	out = append(out, r.buf[r.next:]...)
+	return append(out, r.buf[:r.next]...)
+}
+
+// printTrace writes DumpTrace to stderr, one event per line.
+func (s *Scheduler) printTrace() {
+	events := s.DumpTrace()
+	fmt.Fprintf(os.Stderr, "toysched: last %d events:\n", len(events))
+	for _, e := range events {
+		fmt.Fprintf(os.Stderr, "  %v %v\n", e.Time.Format("15:04:05.000000"), e)
+	}
+}
+
+// Events returns the scheduler's event stream. Sends never block the
+// scheduler: if the buffer is full the event is dropped.
+func (s *Scheduler) Events() <-chan SchedEvent {
+	return s.events
+}
+
+// Size of the Completions buffer; when it is full the oldest G is
+// dropped to make room.
+const completionBuffer = 256
+
+// Completions returns a stream of Gs as they finish (Done, Failed or
+// Cancelled), the push counterpart to polling Stats().Completed. Each G
+// is delivered once. Sends never block the scheduler: if the consumer
+// falls completionBuffer Gs behind, the oldest undelivered G is dropped.
+func (s *Scheduler) Completions() <-chan *G {
+	return s.completions
+}
+
+// complete delivers g on Completions, dropping the oldest G if the
+// buffer is full.
+func (s *Scheduler) complete(g *G) {
+	for {
+		select {
+		case s.completions <- g:
+			return
+		default:
+		}
+		select {
+		case <-s.completions:
+		default:
+		}
+	}
+}
+
+// SetConsole logs events and G progress lines as text to w, at Info
+// level, in place of the current logger (see WithLogger); nil discards
+// them. Call it before Run.
+func (s *Scheduler) SetConsole(w io.Writer) {
+	if w == nil {
+		s.logger = slog.New(slog.DiscardHandler)
+		return
+	}
+	s.logger = newTextLogger(w)
+}
+
+// emit publishes e to the logger and the Events channel.
+func (s *Scheduler) emit(e SchedEvent) {
+	e.Time = s.now()
+	s.ring.add(e)
+	s.logEvent(e)
+	select {
+	case s.events <- e:
+	default:
+	}
+}
+
+// printf logs free-form progress text as an Info record.
+func (s *Scheduler) printf(format string, args ...any) {
+	s.logf(format, args...)
+}
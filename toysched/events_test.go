@@ -0,0 +1,169 @@
+package toysched
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDumpTraceAfterDeadlock(t *testing.T) {
+	s := newDeterministic(t, 1, 2)
+	s.DeadlockTicks = 2
+	s.Enqueue(s.Ps[0], s.NewG(func() {}, false))
+	s.Step() // M0 runs the G
+	s.Step() // M1 has no P
+	s.Step() // M0 finds nothing and parks P0
+	// Lose P0, then give it work nobody can reach.
+	<-s.availPs
+	s.Ps[0].push(s.NewG(func() {}, false))
+
+	stderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+	var msg any
+	func() {
+		defer func() { msg = recover() }()
+		for range 10 {
+			s.Step()
+		}
+	}()
+	w.Close()
+	os.Stderr = stderr
+	var printed bytes.Buffer
+	io.Copy(&printed, r)
+
+	if msg == nil {
+		t.Fatal("the watchdog did not report the deadlock")
+	}
+	kinds := make(map[EventKind]bool)
+	for _, e := range s.DumpTrace() {
+		kinds[e.Kind] = true
+	}
+	for _, k := range []EventKind{EventStart, EventFinish, EventPark} {
+		if !kinds[k] {
+			t.Errorf("trace has no %v event", k)
+		}
+	}
+	if out := printed.String(); !strings.Contains(out, "toysched: last ") || !strings.Contains(out, "Parking, handing off P0") {
+		t.Errorf("deadlock did not print the trace; stderr:\n%s", out)
+	}
+}
+
+func TestDumpTraceKeepsLatest(t *testing.T) {
+	tests := []struct {
+		name  string
+		added int
+	}{
+		{"empty", 0},
+		{"partly full", 10},
+		{"exactly full", traceRingSize},
+		{"wrapped", traceRingSize + 37},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Scheduler{}
+			for i := range tt.added {
+				s.ring.add(SchedEvent{GID: i})
+			}
+			got := s.DumpTrace()
+			if want := min(tt.added, traceRingSize); len(got) != want {
+				t.Fatalf("%d events, want %d", len(got), want)
+			}
+			for i, e := range got {
+				if want := tt.added - len(got) + i; e.GID != want {
+					t.Fatalf("event %d is G%d, want G%d (oldest first)", i, e.GID, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCompletionsDeliversEachGOnce(t *testing.T) {
+	s, _ := NewScheduler(4, 4, WithLogger(nil))
+	s.TickInterval = time.Millisecond
+	s.Run()
+	defer s.Stop()
+
+	const n = 50
+	submitted := make(map[*G]bool)
+	for range n {
+		g, err := s.Submit(func() {})
+		if err != nil {
+			t.Fatal(err)
+		}
+		submitted[g] = true
+	}
+	got := make(map[*G]bool)
+	timeout := time.After(5 * time.Second)
+	for len(got) < n {
+		select {
+		case g := <-s.Completions():
+			if got[g] {
+				t.Fatalf("G%d delivered twice", g.ID)
+			}
+			if !submitted[g] {
+				t.Fatalf("G%d was never submitted", g.ID)
+			}
+			if g.Status() != Done {
+				t.Fatalf("G%d delivered as %v, want Done", g.ID, g.Status())
+			}
+			got[g] = true
+		case <-timeout:
+			t.Fatalf("received %d of %d Gs", len(got), n)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.WaitIdle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case g := <-s.Completions():
+		t.Fatalf("extra delivery of G%d", g.ID)
+	default:
+	}
+}
+
+func TestCompletionsDropsOldestWhenFull(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+	}{
+		{"under the buffer", completionBuffer / 2},
+		{"exactly the buffer", completionBuffer},
+		{"over the buffer", completionBuffer + 44},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, 1, 1)
+			var ids []int
+			for range tt.n {
+				g := s.NewG(func() {}, false)
+				ids = append(ids, g.ID)
+				s.Enqueue(s.Ps[0], g)
+			}
+			// Nobody reads until the end: the scheduler must not block.
+			s.RunDeterministic()
+
+			want := ids[max(0, tt.n-completionBuffer):]
+			var got []int
+			for len(got) < len(want) {
+				got = append(got, (<-s.Completions()).ID)
+			}
+			select {
+			case g := <-s.Completions():
+				t.Fatalf("extra delivery of G%d", g.ID)
+			default:
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Fatalf("delivered %v..., want the newest %d Gs %v...", got[:3], len(want), want[:3])
+				}
+			}
+		})
+	}
+}
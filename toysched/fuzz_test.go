@@ -0,0 +1,162 @@
+package toysched
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// Steps replayOps allows for draining before it reports a livelock:
+// a fixed floor plus a share for every G submitted, so a long input
+// isn't mistaken for one that stopped making progress.
+const (
+	minDrainSteps  = 10000
+	drainStepsPerG = 10
+)
+
+func FuzzScheduler(f *testing.F) {
+	for _, seed := range [][]byte{
+		nil,
+		{0},
+		{15, 2, 2},
+		{1, 6, 2, 3, 2},
+		{15, 1, 6, 37, 3, 8, 15, 37},
+		{1, 1, 1, 1, 37, 37},
+		{15, 15, 15, 15, 3, 37, 1, 37, 3},
+		{15, 1, 37, 4, 15, 1, 37, 3},
+		bytes.Repeat([]byte{15}, 2700), // more Gs than minDrainSteps
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if err := replayOps(data); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// replayOps drives a fresh 2-P, 2-M scheduler in deterministic mode
+// through the operations encoded in data, running Check and verifying
+// that no G is lost after each one, and returns the first violation.
+//
+// Each byte is one operation: the byte mod 5 picks it, the quotient is
+// its argument. 0 submits 1-4 Gs, 1 enqueues a blocking G on a P, 2
+// steps the scheduler 1-8 times, 3 unblocks one of the blocked Gs, and
+// 4 stops the scheduler, after which submitting must fail with
+// ErrSchedulerStopped. Since everything runs on the caller's goroutine,
+// a failing input always replays the same way. Once data is used up,
+// the scheduler runs to completion, every G being woken as soon as it
+// blocks.
+func replayOps(data []byte) error {
+	s, err := NewScheduler(2, 2, WithDeterministic())
+	if err != nil {
+		return err
+	}
+	s.SetConsole(nil)
+	submitted, stopped := 0, false
+	// queued checks the outcome of queueing one G: it must succeed
+	// until Stop and fail with ErrSchedulerStopped after it.
+	queued := func(i int, err error) error {
+		switch {
+		case stopped && !errors.Is(err, ErrSchedulerStopped):
+			return fmt.Errorf("op %d: queued after Stop: %v", i, err)
+		case !stopped && err != nil:
+			return fmt.Errorf("op %d: %w", i, err)
+		case !stopped:
+			submitted++
+		}
+		return nil
+	}
+	for i, b := range data {
+		op, arg := b%5, int(b/5)
+		switch op {
+		case 0:
+			for range arg%4 + 1 {
+				_, err := s.Submit(func() {})
+				if err := queued(i, err); err != nil {
+					return err
+				}
+			}
+		case 1:
+			err := s.Enqueue(s.Ps[arg%len(s.Ps)], s.NewG(func() {}, true))
+			if err := queued(i, err); err != nil {
+				return err
+			}
+		case 2:
+			for range arg%8 + 1 {
+				s.Step()
+			}
+		case 3:
+			if ids := s.blockedIDs(); len(ids) > 0 {
+				s.Unblock(ids[arg%len(ids)])
+			}
+		case 4:
+			s.Stop()
+			stopped = true
+		}
+		if err := s.checkAccounting(submitted); err != nil {
+			return fmt.Errorf("after op %d (%d): %w", i, op, err)
+		}
+	}
+
+	// Drain, waking each G as it blocks. Every M may be stuck in a
+	// "syscall" with Gs still queued, so this can't just RunDeterministic.
+	budget := minDrainSteps + drainStepsPerG*submitted
+	for steps := 0; ; steps++ {
+		if steps == budget {
+			return fmt.Errorf("drain: no progress after %d steps", steps)
+		}
+		for _, id := range s.blockedIDs() {
+			s.Unblock(id)
+		}
+		if !s.Step() && len(s.blockedIDs()) == 0 {
+			break
+		}
+	}
+	if err := s.checkAccounting(submitted); err != nil {
+		return fmt.Errorf("after drain: %w", err)
+	}
+	if st := s.Stats(); st.Completed != submitted {
+		return fmt.Errorf("after drain: %d of %d Gs completed", st.Completed, submitted)
+	}
+	return nil
+}
+
+// blockedIDs returns the IDs of the currently blocked Gs, sorted.
+func (s *Scheduler) blockedIDs() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]int, 0, len(s.blockedGs))
+	for id := range s.blockedGs {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// checkAccounting runs Check and verifies that every one of the
+// submitted Gs is queued, held by an M or finished. Only meaningful
+// between Steps, when no G is mid-run.
+func (s *Scheduler) checkAccounting(submitted int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkLocked(); err != nil {
+		return err
+	}
+	total := len(s.globalQ)
+	for _, p := range s.queuesLocked() {
+		total += len(p.RunQ)
+	}
+	for _, m := range s.Ms {
+		if m.G != nil {
+			total++
+		}
+	}
+	total += s.completed + s.failed + s.cancelled
+	if total != submitted {
+		return fmt.Errorf("%d Gs accounted for, %d submitted", total, submitted)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package toysched
+
+import "fmt"
+
+// Close is the canonical teardown: it stops every M and background
+// goroutine (see Stop) and waits for them to return. Gs that were
+// started but never finished, such as one parked on a SchedChan nobody
+// will send on, are still holding a goroutine that can't be reclaimed;
+// Close reports them as an error. Calling Close again is harmless.
+func (s *Scheduler) Close() error {
+	s.Stop()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n := len(s.gs); n > 0 {
+		return fmt.Errorf("toysched: %d G(s) still parked after Close; their goroutines leak", n)
+	}
+	return nil
+}
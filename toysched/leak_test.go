@@ -0,0 +1,41 @@
+package toysched
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCloseReportsParkedGs(t *testing.T) {
+	tests := []struct {
+		name    string
+		park    bool
+		wantErr bool
+	}{
+		{"all finished", false, false},
+		{"G parked on a channel", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := NewScheduler(1, 1)
+			s.SetConsole(nil)
+			s.EventDriven = true
+			ch := s.NewChan()
+			g, _ := s.Submit(func() {
+				if tt.park {
+					ch.Recv()
+				}
+			})
+			s.Run()
+			for g.Status() != Done && g.Status() != Waiting {
+				time.Sleep(time.Millisecond)
+			}
+			err := s.Close()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Close: err = %v, want error %v", err, tt.wantErr)
+			}
+			if err := s.Close(); (err != nil) != tt.wantErr {
+				t.Fatalf("second Close: err = %v", err)
+			}
+		})
+	}
+}
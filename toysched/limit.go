@@ -0,0 +1,44 @@
+package toysched
+
+// SetMaxRunning caps how many Gs may be Running at once, however many
+// Ms and Ps there are, like a worker pool on top of the M/P machinery.
+// An M that finds the cap reached keeps its P but starts no G that
+// round; one returning from a syscall waits likewise. n <= 0 removes
+// the cap.
+func (s *Scheduler) SetMaxRunning(n int) {
+	s.mu.Lock()
+	s.maxRunning = max(n, 0)
+	s.wakeMs()
+	s.mu.Unlock()
+}
+
+// atRunLimitLocked reports whether the SetMaxRunning cap leaves no room
+// for another G to start. Caller must hold s.mu.
+func (s *Scheduler) atRunLimitLocked() bool {
+	return s.maxRunning > 0 && s.running >= s.maxRunning
+}
+
+// reserveGLocked claims room for one more live G under MaxGs, failing
+// with ErrShutdown once Shutdown has begun, ErrSchedulerStopped after
+// Stop, and ErrTooManyGs at the cap.
+// The caller creates the G and then gives the room back with
+// reservedGs--, as the G itself now counts. Caller must hold s.mu.
+func (s *Scheduler) reserveGLocked() error {
+	if s.shutdown {
+		return ErrShutdown
+	}
+	if s.stoppedLocked() {
+		return ErrSchedulerStopped
+	}
+	if s.MaxGs > 0 && s.liveGsLocked()+s.reservedGs >= s.MaxGs {
+		return ErrTooManyGs
+	}
+	s.reservedGs++
+	return nil
+}
+
+// liveGsLocked counts the Gs created and not yet finished.
+// Caller must hold s.mu.
+func (s *Scheduler) liveGsLocked() int {
+	return s.created - s.completed - s.failed - s.cancelled
+}
@@ -0,0 +1,104 @@
+package toysched
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaxGsFreesSlotBeforeWaitReturns(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		s, err := NewScheduler(1, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.SetConsole(nil)
+		s.MaxGs = 1
+		s.Run()
+
+		g, err := s.Submit(func() {})
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.Wait()
+		if got := s.Stats().Completed; got != 1 {
+			t.Fatalf("run %d: Completed = %d after Wait, want 1", i, got)
+		}
+		if _, err := s.Submit(func() {}); err != nil {
+			t.Fatalf("run %d: second Submit: %v", i, err)
+		}
+		s.Stop()
+	}
+}
+
+func TestMaxGsRejectsAtCap(t *testing.T) {
+	s, _ := NewScheduler(1, 1)
+	s.SetConsole(nil)
+	s.MaxGs = 2
+	for i := 0; i < 2; i++ {
+		if _, err := s.Submit(func() {}); err != nil {
+			t.Fatalf("Submit %d: %v", i, err)
+		}
+	}
+	if _, err := s.Submit(func() {}); !errors.Is(err, ErrTooManyGs) {
+		t.Fatalf("Submit at cap: err = %v, want ErrTooManyGs", err)
+	}
+}
+
+func TestSetMaxRunningCapsConcurrency(t *testing.T) {
+	tests := []struct {
+		name string
+		max  int
+		want int32
+	}{
+		{"cap 1", 1, 1},
+		{"cap 2", 2, 2},
+		{"no cap", 0, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := NewScheduler(4, 4, WithLogger(nil))
+			s.TickInterval = time.Millisecond
+			s.SetMaxRunning(tt.max)
+			var running, peak atomic.Int32
+			for range 40 {
+				s.Submit(func() {
+					n := running.Add(1)
+					for old := peak.Load(); n > old && !peak.CompareAndSwap(old, n); old = peak.Load() {
+					}
+					time.Sleep(2 * time.Millisecond)
+					running.Add(-1)
+				})
+			}
+			s.RunAll()
+			if st := s.Stats(); st.Completed != 40 {
+				t.Fatalf("Completed = %d, want 40", st.Completed)
+			}
+			if got := peak.Load(); got != tt.want {
+				t.Fatalf("peak running = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetMaxRunningStepped(t *testing.T) {
+	s := newDeterministic(t, 2, 2)
+	s.SetMaxRunning(1)
+	blocked := s.NewGBlocking(func() {}, 0)
+	other := s.NewG(func() {}, false)
+	s.Enqueue(s.Ps[0], blocked)
+	s.Enqueue(s.Ps[1], other)
+
+	// A blocked G has left the Running state, so it doesn't hold the slot.
+	s.Step()
+	s.Step()
+	if other.Status() != Done {
+		t.Fatalf("other G is %v while the only running G is blocked", other.Status())
+	}
+	blocked.Unblock()
+	s.RunDeterministic()
+	if blocked.Status() != Done {
+		t.Fatalf("blocked G is %v", blocked.Status())
+	}
+}
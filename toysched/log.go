@@ -0,0 +1,66 @@
+package toysched
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// WithLogger sends scheduler events and G progress lines to l as
+// structured records. By default they go to os.Stdout through a text
+// handler at Info level; see also SetConsole. Each event is an Info
+// record named after its kind ("grab", "park", "steal",
+// "start", "finish", "block", ...) with m, p and g attributes (-1 where
+// they don't apply), plus from and n for steals and overflows; a failed
+// G is logged at Warn. Progress lines are Info records. Use the
+// handler's level to filter, a JSON handler for parseable output, or nil
+// to discard everything.
+func WithLogger(l *slog.Logger) Option {
+	return func(s *Scheduler) {
+		if l == nil {
+			l = slog.New(slog.DiscardHandler)
+		}
+		s.logger = l
+	}
+}
+
+// newTextLogger is the default logger: text records at Info and above.
+func newTextLogger(w io.Writer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: slog.LevelInfo}))
+}
+
+// logEvent writes e to s.logger.
+func (s *Scheduler) logEvent(e SchedEvent) {
+	level := slog.LevelInfo
+	if e.Kind == EventFail {
+		level = slog.LevelWarn
+	}
+	attrs := []slog.Attr{slog.Int("m", e.MID), slog.Int("p", e.PID), slog.Int("g", e.GID)}
+	if e.Kind == EventSteal || e.Kind == EventOverflow {
+		attrs = append(attrs, slog.Int("from", e.From), slog.Int("n", e.N))
+	}
+	s.log(level, e.Kind.String(), attrs...)
+}
+
+// logf writes a printf progress line to s.logger, without its newline.
+func (s *Scheduler) logf(format string, args ...any) {
+	if !s.logger.Enabled(context.Background(), slog.LevelInfo) {
+		return
+	}
+	s.log(slog.LevelInfo, strings.TrimSpace(fmt.Sprintf(format, args...)))
+}
+
+// log writes a record stamped with the scheduler's clock, so output in
+// deterministic mode is the same on every run.
+func (s *Scheduler) log(level slog.Level, msg string, attrs ...slog.Attr) {
+	ctx := context.Background()
+	h := s.logger.Handler()
+	if !h.Enabled(ctx, level) {
+		return
+	}
+	r := slog.NewRecord(s.now(), level, msg, 0)
+	r.AddAttrs(attrs...)
+	h.Handle(ctx, r)
+}
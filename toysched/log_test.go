@@ -0,0 +1,141 @@
+package toysched
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// captureHandler keeps every record it is given.
+type captureHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *captureHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *captureHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *captureHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r.Clone())
+	return nil
+}
+
+// find returns the attributes of the first record with message msg.
+func (h *captureHandler) find(msg string) (map[string]int64, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, r := range h.records {
+		if r.Message != msg {
+			continue
+		}
+		attrs := make(map[string]int64)
+		r.Attrs(func(a slog.Attr) bool {
+			attrs[a.Key] = a.Value.Int64()
+			return true
+		})
+		return attrs, true
+	}
+	return nil, false
+}
+
+func TestLoggerRecordsSteal(t *testing.T) {
+	h := &captureHandler{}
+	s := newDeterministic(t, 2, 2, WithLogger(slog.New(h)))
+	// Both Gs on P0, so M1 has to steal one from it.
+	g0, g1 := s.NewG(func() {}, false), s.NewG(func() {}, false)
+	s.Enqueue(s.Ps[0], g0)
+	s.Enqueue(s.Ps[0], g1)
+	s.RunDeterministic()
+
+	attrs, ok := h.find("steal")
+	if !ok {
+		t.Fatal("no steal record")
+	}
+	if attrs["m"] != 1 || attrs["p"] != 1 || attrs["from"] != 0 || attrs["n"] != 1 {
+		t.Fatalf("steal record attrs = %v", attrs)
+	}
+	if attrs["g"] != int64(g0.ID) && attrs["g"] != int64(g1.ID) {
+		t.Fatalf("steal record g = %d, want G%d or G%d", attrs["g"], g0.ID, g1.ID)
+	}
+	if _, ok := h.find("finish"); !ok {
+		t.Fatal("no finish record")
+	}
+}
+
+func TestDefaultLoggerIsInfoText(t *testing.T) {
+	var buf bytes.Buffer
+	s := newDeterministic(t, 1, 1)
+	s.SetConsole(&buf)
+	s.Enqueue(s.Ps[0], s.NewG(func() {}, false))
+	s.RunDeterministic()
+	out := buf.String()
+	for _, want := range []string{"level=INFO msg=start m=0 p=0 g=0", "time=1970-01-01"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+
+	s, _ = NewScheduler(1, 1)
+	if !s.logger.Enabled(context.Background(), slog.LevelInfo) || s.logger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("default logger is not at Info level")
+	}
+}
+
+func TestWithLoggerNilDiscards(t *testing.T) {
+	s := newDeterministic(t, 1, 1, WithLogger(nil))
+	if s.logger.Enabled(context.Background(), slog.LevelError) {
+		t.Fatal("WithLogger(nil) still logs")
+	}
+}
+
+func TestLoggerLevelsAndJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		level slog.Level
+		want  []string // messages logged, in order
+	}{
+		{"warn keeps only the failure", slog.LevelWarn, []string{"fail"}},
+		{"info keeps every event", slog.LevelInfo, []string{"start", "fail", "start", "finish"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: tt.level}))
+			s := newDeterministic(t, 1, 1, WithLogger(l))
+			s.Enqueue(s.Ps[0], s.NewG(func() { panic("boom") }, false))
+			s.Enqueue(s.Ps[0], s.NewG(func() {}, false))
+			s.RunDeterministic()
+
+			var got []string
+			dec := json.NewDecoder(&buf)
+			for dec.More() {
+				var rec struct {
+					Level string
+					Msg   string
+					G     *int
+				}
+				if err := dec.Decode(&rec); err != nil {
+					t.Fatalf("output is not JSON: %v", err)
+				}
+				if rec.Msg == "fail" && (rec.Level != "WARN" || rec.G == nil || *rec.G != 0) {
+					t.Errorf("fail record = %+v, want WARN with g=0", rec)
+				}
+				// Keep the events; progress lines vary in wording.
+				if slices.Contains(eventNames[:], rec.Msg) {
+					got = append(got, rec.Msg)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("logged %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
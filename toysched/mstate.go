@@ -0,0 +1,80 @@
+package toysched
+
+import (
+	"fmt"
+	"time"
+)
+
+// MState is what an M is doing, like the runtime's M states.
+type MState int32
+
+const (
+	// Holding a P and looking for a G to run.
+	MSpinning MState = iota
+	// Executing a G.
+	MRunning
+	// Holding neither P nor G: waiting (or cooling down) to grab a P.
+	MParked
+	// Waiting with a blocked G, as if in a syscall.
+	MSyscall
+
+	numMStates
+)
+
+func (st MState) String() string {
+	switch st {
+	case MSpinning:
+		return "spinning"
+	case MRunning:
+		return "running"
+	case MParked:
+		return "parked"
+	case MSyscall:
+		return "syscall"
+	}
+	return fmt.Sprintf("MState(%d)", int(st))
+}
+
+// MStateTicks counts the scheduling rounds an M spent in each state. A
+// round that ran a G counts as Running; any other round counts in the
+// state the M was left in.
+type MStateTicks struct {
+	Spinning, Running, Parked, Syscall int
+}
+
+// State reports what the M is currently doing.
+func (m *M) State() MState {
+	return MState(m.state.Load())
+}
+
+func (m *M) setState(st MState) {
+	m.state.Store(int32(st))
+}
+
+// tick counts one finished scheduling round; see MStateTicks.
+func (m *M) tick() {
+	st := m.State()
+	if m.ran {
+		st = MRunning
+		m.ran = false
+	}
+	m.ticks[st].Add(1)
+}
+
+// stateTicks snapshots the M's per-state round counts.
+func (m *M) stateTicks() MStateTicks {
+	return MStateTicks{
+		Spinning: int(m.ticks[MSpinning].Load()),
+		Running:  int(m.ticks[MRunning].Load()),
+		Parked:   int(m.ticks[MParked].Load()),
+		Syscall:  int(m.ticks[MSyscall].Load()),
+	}
+}
+
+// utilization is the percentage of elapsed that m spent running Gs.
+func (m *M) utilization(elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return min(100*float64(m.busy.Load())/float64(elapsed), 100)
+}
@@ -0,0 +1,103 @@
+package toysched
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMStateThroughSyscall(t *testing.T) {
+	s := newDeterministic(t, 1, 1)
+	m := s.Ms[0]
+	g := s.NewGBlocking(func() {}, 0)
+	s.Enqueue(s.Ps[0], g)
+	if m.State() != MSpinning {
+		t.Fatalf("before the first Step: %v, want spinning", m.State())
+	}
+
+	steps := []struct {
+		name   string
+		before func()
+		want   MState
+	}{
+		{"G blocks", nil, MSyscall},
+		{"still blocked", nil, MSyscall},
+		{"unblocked and finished", func() { g.Unblock() }, MSpinning},
+		{"nothing to run", nil, MParked},
+	}
+	for _, st := range steps {
+		if st.before != nil {
+			st.before()
+		}
+		s.Step()
+		if got := m.State(); got != st.want {
+			t.Fatalf("%s: M is %v, want %v", st.name, got, st.want)
+		}
+		if got := s.Stats().MStates[0]; got != st.want {
+			t.Fatalf("%s: Stats().MStates[0] = %v, want %v", st.name, got, st.want)
+		}
+	}
+
+	// The first and third rounds ran the G.
+	want := MStateTicks{Running: 2, Syscall: 1, Parked: 1}
+	if got := s.Stats().MStateTicks[0]; got != want {
+		t.Fatalf("MStateTicks = %+v, want %+v", got, want)
+	}
+}
+
+func TestMStateString(t *testing.T) {
+	tests := []struct {
+		st   MState
+		want string
+	}{
+		{MSpinning, "spinning"},
+		{MRunning, "running"},
+		{MParked, "parked"},
+		{MSyscall, "syscall"},
+		{MState(9), "MState(9)"},
+	}
+	for _, tt := range tests {
+		if got := tt.st.String(); got != tt.want {
+			t.Errorf("MState(%d).String() = %q, want %q", int(tt.st), got, tt.want)
+		}
+	}
+}
+
+func TestMUtilizationShowsImbalance(t *testing.T) {
+	s, _ := NewScheduler(2, 2, WithLogger(nil))
+	s.TickInterval = time.Millisecond
+	// Pinned, so the idle M can't even the load out by stealing.
+	for range 20 {
+		s.Enqueue(s.Ps[0], s.NewGPinned(func() { spin(2 * time.Millisecond) }, 0))
+	}
+	s.Enqueue(s.Ps[1], s.NewG(func() {}, false))
+	s.RunAll()
+
+	u := s.Stats().MUtilization
+	if len(u) != 2 {
+		t.Fatalf("MUtilization = %v, want one entry per M", u)
+	}
+	// Relative, not absolute: on a loaded machine M0's share of the
+	// wall clock drops, but M1 should still sit at a fraction of it.
+	if u[0] < 20 || u[1] > u[0]/4 {
+		t.Fatalf("MUtilization = %.1f%%, want M0 busy and M1 mostly idle", u)
+	}
+}
+
+func TestUtilization(t *testing.T) {
+	tests := []struct {
+		busy, elapsed time.Duration
+		want          float64
+	}{
+		{0, time.Second, 0},
+		{250 * time.Millisecond, time.Second, 25},
+		{2 * time.Second, time.Second, 100},
+		{time.Second, 0, 0},
+	}
+	for _, tt := range tests {
+		m := &M{}
+		m.busy.Store(int64(tt.busy))
+		if got := m.utilization(tt.elapsed); got != tt.want {
+			t.Errorf("utilization(%v busy of %v) = %v, want %v", tt.busy, tt.elapsed, got, tt.want)
+		}
+	}
+}
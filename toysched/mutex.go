@@ -0,0 +1,9 @@
+//go:build !schedrace
+
+package toysched
+
+import "sync"
+
+// schedMutex is the scheduler lock. Build with -tags schedrace to swap
+// in an audited version that catches unbalanced Lock/Unlock calls.
+type schedMutex = sync.Mutex
@@ -0,0 +1,44 @@
+//go:build schedrace
+
+package toysched
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// schedMutex is the scheduler lock with auditing, for -tags schedrace.
+// It remembers which goroutine holds it and panics at the offending
+// call, rather than somewhere in the runtime, on the mistakes that broke
+// step5: unlocking it twice, unlocking it from a goroutine that doesn't
+// hold it, and locking it again while already holding it (which would
+// otherwise just deadlock). Every call pays for a goroutine ID lookup,
+// so keep it out of production builds.
+type schedMutex struct {
+	mu sync.Mutex
+	// Goroutine holding mu, 0 when unlocked.
+	holder atomic.Uint64
+}
+
+func (m *schedMutex) Lock() {
+	id := goid()
+	if m.holder.Load() == id {
+		panic(fmt.Sprintf("toysched: recursive lock of scheduler mutex by goroutine %d", id))
+	}
+	m.mu.Lock()
+	m.holder.Store(id)
+}
+
+func (m *schedMutex) Unlock() {
+	id := goid()
+	switch holder := m.holder.Load(); holder {
+	case 0:
+		panic("toysched: double unlock of scheduler mutex")
+	case id:
+	default:
+		panic(fmt.Sprintf("toysched: unlock of scheduler mutex by goroutine %d, held by %d", id, holder))
+	}
+	m.holder.Store(0)
+	m.mu.Unlock()
+}
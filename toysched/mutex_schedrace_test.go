@@ -0,0 +1,54 @@
+//go:build schedrace
+
+package toysched
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSchedMutexAudit(t *testing.T) {
+	tests := []struct {
+		name string
+		f    func(m *schedMutex)
+		want string
+	}{
+		{"double unlock", func(m *schedMutex) {
+			m.Lock()
+			m.Unlock()
+			m.Unlock()
+		}, "double unlock of scheduler mutex"},
+		{"unlock by non-holder", func(m *schedMutex) {
+			m.Lock()
+			done := make(chan any)
+			go func() {
+				defer func() { done <- recover() }()
+				m.Unlock()
+			}()
+			panic(<-done)
+		}, "unlock of scheduler mutex by goroutine"},
+		{"recursive lock", func(m *schedMutex) {
+			m.Lock()
+			m.Lock()
+		}, "recursive lock of scheduler mutex"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				msg, _ := recover().(string)
+				if !strings.Contains(msg, tt.want) {
+					t.Fatalf("panic = %q, want it to mention %q", msg, tt.want)
+				}
+			}()
+			tt.f(new(schedMutex))
+		})
+	}
+}
+
+func TestSchedMutexBalancedUse(t *testing.T) {
+	s, _ := NewScheduler(2, 2, WithLogger(nil))
+	for range 20 {
+		s.Submit(func() {})
+	}
+	s.RunAll()
+}
@@ -0,0 +1,73 @@
+package toysched
+
+import "fmt"
+
+// pollDesc is the toy counterpart of the runtime's pollDesc: the Gs
+// parked waiting for an fd, and whether a readiness signal arrived while
+// nobody was waiting. Guarded by s.mu.
+type pollDesc struct {
+	waiters []*G
+	ready   bool
+}
+
+// RegisterIO registers fd with the scheduler's netpoller so Gs can wait
+// on it with WaitIO. The fd is just a key; nothing is opened.
+func (s *Scheduler) RegisterIO(fd int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.fds[fd]; ok {
+		return fmt.Errorf("toysched: fd %d already registered", fd)
+	}
+	if s.fds == nil {
+		s.fds = make(map[int]*pollDesc)
+	}
+	s.fds[fd] = &pollDesc{}
+	return nil
+}
+
+// WaitIO parks the calling G until fd is marked ready. Like a G blocked
+// in the runtime's netpoller, it doesn't tie up an M: its M and P move on
+// to other work, and MarkReady requeues it on a P. If fd was marked ready
+// while nobody was waiting, WaitIO consumes that and returns at once. It
+// must be called from inside a running G.
+func (s *Scheduler) WaitIO(fd int) error {
+	g := s.mustCurrent("WaitIO")
+	var err error
+	s.gopark(g, func() bool {
+		pd, ok := s.fds[fd]
+		if !ok {
+			err = fmt.Errorf("toysched: fd %d not registered", fd)
+			return false
+		}
+		if pd.ready {
+			pd.ready = false
+			return false
+		}
+		pd.waiters = append(pd.waiters, g)
+		return true
+	})
+	return err
+}
+
+// MarkReady signals that fd is ready, waking every G waiting on it, and
+// returns how many woke. With no waiters the readiness is remembered for
+// the next WaitIO. It may be called from any goroutine, such as a
+// poller delivering readiness in the background.
+func (s *Scheduler) MarkReady(fd int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pd, ok := s.fds[fd]
+	if !ok {
+		return 0, fmt.Errorf("toysched: fd %d not registered", fd)
+	}
+	n := len(pd.waiters)
+	if n == 0 {
+		pd.ready = true
+		return 0, nil
+	}
+	for _, g := range pd.waiters {
+		s.readyLocked(g)
+	}
+	pd.waiters = nil
+	return n, nil
+}
@@ -0,0 +1,87 @@
+package toysched
+
+import "testing"
+
+func TestMarkReadyWakesOnlyThatFd(t *testing.T) {
+	s := newDeterministic(t, 2, 2)
+	for _, fd := range []int{3, 4} {
+		if err := s.RegisterIO(fd); err != nil {
+			t.Fatal(err)
+		}
+	}
+	byFd := make(map[int][]*G)
+	for i := range 6 {
+		fd := 3 + i%2
+		g := s.NewG(func() {
+			if err := s.WaitIO(fd); err != nil {
+				t.Error(err)
+			}
+		}, false)
+		s.Enqueue(s.Ps[i%2], g)
+		byFd[fd] = append(byFd[fd], g)
+	}
+	s.RunDeterministic()
+	for _, gs := range byFd {
+		for _, g := range gs {
+			if g.Status() != Waiting {
+				t.Fatalf("G%d is %v before any fd is ready, want Waiting", g.ID, g.Status())
+			}
+		}
+	}
+
+	if n, err := s.MarkReady(3); err != nil || n != 3 {
+		t.Fatalf("MarkReady(3) = %d, %v; want 3 woken", n, err)
+	}
+	s.RunDeterministic()
+	for fd, want := range map[int]GStatus{3: Done, 4: Waiting} {
+		for _, g := range byFd[fd] {
+			if g.Status() != want {
+				t.Fatalf("G%d on fd %d is %v, want %v", g.ID, fd, g.Status(), want)
+			}
+		}
+	}
+}
+
+func TestNetpollErrorsAndEarlyReadiness(t *testing.T) {
+	tests := []struct {
+		name    string
+		run     func(s *Scheduler) error
+		wantErr bool
+	}{
+		{"register twice", func(s *Scheduler) error {
+			s.RegisterIO(1)
+			return s.RegisterIO(1)
+		}, true},
+		{"mark unregistered", func(s *Scheduler) error {
+			_, err := s.MarkReady(9)
+			return err
+		}, true},
+		{"wait unregistered", func(s *Scheduler) error {
+			var err error
+			s.Enqueue(s.Ps[0], s.NewG(func() { err = s.WaitIO(9) }, false))
+			s.RunDeterministic()
+			return err
+		}, true},
+		{"ready before wait", func(s *Scheduler) error {
+			s.RegisterIO(1)
+			if n, err := s.MarkReady(1); n != 0 || err != nil {
+				return err
+			}
+			g := s.NewG(func() { s.WaitIO(1) }, false)
+			s.Enqueue(s.Ps[0], g)
+			s.RunDeterministic()
+			if g.Status() != Done {
+				t.Errorf("G is %v; the earlier readiness was lost", g.Status())
+			}
+			return nil
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, 1, 1)
+			if err := tt.run(s); (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
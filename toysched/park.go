@@ -0,0 +1,99 @@
+package toysched
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+// Yield requeues the calling G at the tail of its P's run queue and lets
+// the M pick the next G. The G carries on from here when it is next
+// scheduled. It must be called from inside a running G's Func.
+func (s *Scheduler) Yield() {
+	g := s.mustCurrent("Yield")
+	g.setStatus(Runnable)
+	g.park()
+}
+
+// Gosched is like runtime.Gosched: it requeues the calling G at the tail
+// of the global queue and lets the M pick the next G. Unlike Yield, which
+// keeps the G on its P, the G may then be resumed by any P. It must be
+// called from inside a running G's Func.
+func (s *Scheduler) Gosched() {
+	g := s.mustCurrent("Gosched")
+	g.global = true
+	g.setStatus(Runnable)
+	g.park()
+}
+
+// gopark parks the calling G. Its M runs commit under s.mu once the G
+// has stopped: if commit reports true the G stays Waiting until someone
+// calls readyLocked on it; if false it is requeued straight away. Doing
+// the check-and-register in commit means a wakeup can't slip in between.
+func (s *Scheduler) gopark(g *G, commit func() bool) {
+	g.commit = commit
+	g.setStatus(Waiting)
+	g.park()
+}
+
+// readyLocked makes a parked G runnable again on the P it last ran on
+// (globalQ when Centralized). Caller must hold s.mu.
+func (s *Scheduler) readyLocked(g *G) {
+	s.waiting--
+	g.setStatus(Runnable)
+	g.readyAt = s.now()
+	if s.Centralized && g.PinnedP < 0 {
+		s.globalQ = append(s.globalQ, g)
+		s.emit(SchedEvent{Kind: EventReady, MID: -1, PID: -1, GID: g.ID})
+		s.wakeMs()
+		return
+	}
+	p := s.liveLocked(g.lastP)
+	p.push(g)
+	s.emit(SchedEvent{Kind: EventReady, MID: -1, PID: p.ID, GID: g.ID})
+	s.wakeMs()
+}
+
+// mustCurrent is current, panicking if called outside a running G.
+func (s *Scheduler) mustCurrent(op string) *G {
+	g := s.current()
+	if g == nil {
+		panic("toysched: " + op + " called outside a running G")
+	}
+	return g
+}
+
+// current returns the G whose Func is running on the calling goroutine.
+func (s *Scheduler) current() *G {
+	id := goid()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.gs[id]
+}
+
+func (s *Scheduler) register(g *G) {
+	id := goid()
+	s.mu.Lock()
+	s.gs[id] = g
+	s.mu.Unlock()
+}
+
+func (s *Scheduler) unregister() {
+	id := goid()
+	s.mu.Lock()
+	delete(s.gs, id)
+	s.mu.Unlock()
+}
+
+// goid parses the calling goroutine's id out of its stack header
+// ("goroutine 42 [running]:"). Fine for a toy; the runtime has no API.
+func goid() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
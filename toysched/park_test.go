@@ -0,0 +1,125 @@
+package toysched
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestYieldAndGoschedRequeue(t *testing.T) {
+	tests := []struct {
+		name        string
+		yield       func(s *Scheduler)
+		wantGlobalQ bool
+	}{
+		{"Yield stays local", (*Scheduler).Yield, false},
+		{"Gosched goes global", (*Scheduler).Gosched, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, 1, 1)
+			g := s.NewG(func() { tt.yield(s) }, false)
+			s.Enqueue(s.Ps[0], g)
+			s.Step()
+
+			if g.Status() != Runnable {
+				t.Fatalf("G is %v, want Runnable", g.Status())
+			}
+			inGlobal := slices.Equal(s.GlobalQueued(), []int{g.ID})
+			onP := slices.Equal(s.QueuedGs(0), []int{g.ID})
+			if inGlobal != tt.wantGlobalQ || onP == tt.wantGlobalQ {
+				t.Fatalf("globalQ %v, P0 %v", s.GlobalQueued(), s.QueuedGs(0))
+			}
+			s.RunDeterministic()
+			if g.Status() != Done {
+				t.Fatalf("G is %v after requeue, want Done", g.Status())
+			}
+		})
+	}
+}
+
+func TestGoschedMigratesBetweenPs(t *testing.T) {
+	s := newDeterministic(t, 2, 2)
+	var gs []*G
+	for range 2 {
+		g := s.NewG(func() {
+			for range 10 {
+				s.Gosched()
+			}
+		}, false)
+		s.Enqueue(s.Ps[0], g)
+		gs = append(gs, g)
+	}
+	s.RunDeterministic()
+
+	ps := make(map[int]map[int]bool)
+	for _, e := range s.DumpTrace() {
+		if e.Kind == EventStart || e.Kind == EventResume {
+			if ps[e.GID] == nil {
+				ps[e.GID] = make(map[int]bool)
+			}
+			ps[e.GID][e.PID] = true
+		}
+	}
+	for _, g := range gs {
+		if g.Status() != Done {
+			t.Fatalf("G%d is %v", g.ID, g.Status())
+		}
+		if len(ps[g.ID]) != 2 {
+			t.Errorf("G%d ran only on Ps %v", g.ID, ps[g.ID])
+		}
+	}
+}
+
+func TestYieldOutsideGPanics(t *testing.T) {
+	s := newDeterministic(t, 1, 1)
+	for name, f := range map[string]func(){"Yield": s.Yield, "Gosched": s.Gosched} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s outside a G did not panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func TestParkCooldownSkipsGrabs(t *testing.T) {
+	tests := []struct {
+		name      string
+		cooldown  int // in ticks
+		wantSkips int
+	}{
+		{"no cooldown", 0, 0},
+		{"one tick", 1, 0},
+		{"five ticks", 5, 4},
+		{"default", -1, 19},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, 1, 1)
+			if tt.cooldown >= 0 {
+				s.ParkCooldown = time.Duration(tt.cooldown) * s.TickInterval
+			}
+			s.Step() // nothing to do: M0 parks P0
+			if s.Ms[0].P != nil {
+				t.Fatal("M0 did not park")
+			}
+			g := s.NewG(func() {}, false)
+			s.Enqueue(s.Ps[0], g)
+			ticks := 0
+			for g.Status() != Done {
+				s.Step()
+				ticks++
+			}
+			if got := s.Stats().CooldownSkips; got != tt.wantSkips {
+				t.Fatalf("CooldownSkips = %d, want %d", got, tt.wantSkips)
+			}
+			// Then one more tick grabs P0 and runs the G.
+			if ticks != tt.wantSkips+1 {
+				t.Fatalf("G ran after %d ticks, want %d", ticks, tt.wantSkips+1)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package toysched
+
+// allParkedTicks is how many watchdog ticks in a row every M must be
+// parked, with nothing queued, before AllParked is signalled; Ms
+// parking for a moment between bursts of work don't count.
+const allParkedTicks = 3
+
+// AllParked returns a channel that receives a value each time the pool
+// settles into a fully idle state: every M parked and no G queued
+// anywhere, for a few ticks in a row. It is signalled once per idle
+// stretch, and a signal nobody has received yet is not repeated. A G in
+// a syscall holds its M, so it keeps the signal off, but unlike with
+// WaitIdle a G parked on a SchedChan or SchedWG does not. Unlike OnIdle
+// the channel can be used in a select. Every call returns the same one.
+func (s *Scheduler) AllParked() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.allParked == nil {
+		s.allParked = make(chan struct{}, 1)
+		if s.launched && !s.watchdog && !s.stoppedLocked() {
+			s.watchdog = true
+			s.wg.Add(1)
+			go s.startWatchdog()
+		}
+	}
+	return s.allParked
+}
+
+// noteParkedLocked records one watchdog tick in which every M was (or
+// was not) parked with nothing queued, signalling AllParked once enough
+// such ticks have run together. Caller must hold s.mu.
+func (s *Scheduler) noteParkedLocked(parked bool) {
+	if !parked {
+		s.parkedTicks = 0
+		return
+	}
+	s.parkedTicks++
+	if s.parkedTicks != allParkedTicks || s.allParked == nil {
+		return
+	}
+	select {
+	case s.allParked <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,82 @@
+package toysched
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllParkedDebounces(t *testing.T) {
+	tests := []struct {
+		name    string
+		ticks   string // one watchdog tick per char: P all parked, b busy
+		signals int
+	}{
+		{"too short", "PPb", 0},
+		{"settles", "PPP", 1},
+		{"once per stretch", "PPPPPPPP", 1},
+		{"blips reset", "PPbPPbPP", 0},
+		{"two stretches", "PPPbPPP", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := NewScheduler(1, 1, WithLogger(nil))
+			ch := s.AllParked()
+			signals := 0
+			for _, c := range tt.ticks {
+				s.mu.Lock()
+				s.noteParkedLocked(c == 'P')
+				s.mu.Unlock()
+				select {
+				case <-ch:
+					signals++
+				default:
+				}
+			}
+			if signals != tt.signals {
+				t.Fatalf("%d signals, want %d", signals, tt.signals)
+			}
+		})
+	}
+}
+
+func TestAllParkedAfterBatch(t *testing.T) {
+	s, _ := NewScheduler(2, 2, WithLogger(nil))
+	s.TickInterval = time.Millisecond
+	s.ParkCooldown = time.Millisecond
+	allParked := s.AllParked()
+	var gs []*G
+	submit := func() {
+		for range 30 {
+			g, err := s.Submit(func() { time.Sleep(200 * time.Microsecond) })
+			if err != nil {
+				t.Fatal(err)
+			}
+			gs = append(gs, g)
+		}
+	}
+	submit()
+	s.Run()
+	defer s.Stop()
+
+	for batch := 1; batch <= 2; batch++ {
+		select {
+		case <-allParked:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("batch %d: no AllParked signal", batch)
+		}
+		for _, g := range gs {
+			if g.Status() != Done {
+				t.Fatalf("batch %d: signalled with G%d %v", batch, g.ID, g.Status())
+			}
+		}
+		// Still idle, but the stretch was already signalled.
+		select {
+		case <-allParked:
+			t.Fatalf("batch %d: signalled twice for one idle stretch", batch)
+		case <-time.After(30 * time.Millisecond):
+		}
+		if batch == 1 {
+			submit()
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package toysched
+
+import "time"
+
+// Pause stops the Ms from starting new scheduling rounds until Resume.
+// Nothing is lost: queues, parked Gs and blocked Gs stay as they are.
+// A G that is already running isn't interrupted, but no further G is
+// started. Pausing is for Run's Ms; Step ignores it.
+func (s *Scheduler) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.resumed == nil {
+		s.resumed = make(chan struct{})
+	}
+}
+
+// Resume lets paused Ms carry on. It does nothing if the scheduler
+// isn't paused.
+func (s *Scheduler) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.resumed != nil {
+		close(s.resumed)
+		s.resumed = nil
+	}
+}
+
+// waitResumed blocks m while the scheduler is paused. It reports false
+// if m was stopped in the meantime.
+func (m *M) waitResumed(s *Scheduler) bool {
+	s.mu.Lock()
+	resumed := s.resumed
+	s.mu.Unlock()
+	if resumed == nil {
+		return true
+	}
+	select {
+	case <-resumed:
+		return true
+	case <-m.stop:
+		return false
+	case <-s.ctx.Done():
+		return false
+	}
+}
+
+// StopTheWorld pauses the scheduler like Pause and then waits for a safe
+// point: every running G has yielded, parked, blocked or finished, so no
+// G is mid-step. This is how the runtime's GC stops goroutines, and like
+// it, a G that never yields holds the world up. The pause lasts until
+// StartTheWorld and is counted in Stats. Calling it while the world is
+// already stopped does nothing.
+func (s *Scheduler) StopTheWorld() {
+	s.mu.Lock()
+	if !s.stwStart.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	s.stwStart = time.Now()
+	if s.resumed == nil {
+		s.resumed = make(chan struct{})
+	}
+	for s.running > 0 {
+		s.idle.Wait()
+	}
+	s.mu.Unlock()
+}
+
+// StartTheWorld ends a StopTheWorld, recording how long the world was
+// stopped, and resumes the Ms.
+func (s *Scheduler) StartTheWorld() {
+	s.mu.Lock()
+	if s.stwStart.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	d := time.Since(s.stwStart)
+	s.stwStart = time.Time{}
+	s.stwCount++
+	s.stwTotal += d
+	s.stwMax = max(s.stwMax, d)
+	s.mu.Unlock()
+	s.Resume()
+}
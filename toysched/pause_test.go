@@ -0,0 +1,124 @@
+package toysched
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPauseHoldsBackNewGs(t *testing.T) {
+	s, _ := NewScheduler(2, 2, WithLogger(nil))
+	s.TickInterval = time.Millisecond
+	s.Run()
+	defer s.Stop()
+
+	s.Pause()
+	s.Pause() // pausing twice is fine
+	var gs []*G
+	for range 10 {
+		g, err := s.Submit(func() {})
+		if err != nil {
+			t.Fatal(err)
+		}
+		gs = append(gs, g)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if st := s.Stats(); st.Completed != 0 {
+		t.Fatalf("%d Gs completed while paused", st.Completed)
+	}
+	for _, g := range gs {
+		if g.Status() != Runnable {
+			t.Fatalf("G%d is %v while paused, want Runnable", g.ID, g.Status())
+		}
+	}
+
+	s.Resume()
+	s.Resume() // and so is resuming twice
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.WaitIdle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if st := s.Stats(); st.Completed != len(gs) {
+		t.Fatalf("Completed = %d after Resume, want %d", st.Completed, len(gs))
+	}
+}
+
+func TestPauseLetsRunningGFinish(t *testing.T) {
+	s, _ := NewScheduler(1, 1, WithLogger(nil))
+	s.TickInterval = time.Millisecond
+	started, release := make(chan struct{}), make(chan struct{})
+	running := s.NewG(func() {
+		close(started)
+		<-release
+	}, false)
+	next := s.NewG(func() {}, false)
+	s.Enqueue(s.Ps[0], running)
+	s.Enqueue(s.Ps[0], next)
+	s.Run()
+	defer s.Stop()
+
+	<-started
+	s.Pause()
+	close(release)
+	running.Wait()
+	time.Sleep(20 * time.Millisecond)
+	if next.Status() != Runnable {
+		t.Fatalf("next G is %v while paused, want Runnable", next.Status())
+	}
+	s.Resume()
+	next.Wait()
+}
+
+func TestStopTheWorldDuringSteadyWork(t *testing.T) {
+	s, _ := NewScheduler(2, 2, WithLogger(nil))
+	s.TickInterval = time.Millisecond
+	s.ParkCooldown = time.Millisecond
+	s.Run()
+	defer s.Stop()
+
+	// Keep the Ms busy with short Gs for the whole test.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil {
+			s.Submit(func() { spin(100 * time.Microsecond) })
+			time.Sleep(50 * time.Microsecond)
+		}
+	}()
+
+	const cycles = 5
+	for range cycles {
+		time.Sleep(5 * time.Millisecond)
+		s.StopTheWorld()
+		s.StopTheWorld() // already stopped: no second pause
+		before := s.Stats().Completed
+		for _, m := range s.State().Ms {
+			if m.G >= 0 {
+				if _, ok := s.RunningOn(m.G); ok {
+					t.Fatalf("G%d running on M%d with the world stopped", m.G, m.ID)
+				}
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		if got := s.Stats().Completed; got != before {
+			t.Fatalf("%d Gs completed with the world stopped", got-before)
+		}
+		s.StartTheWorld()
+	}
+	s.StartTheWorld() // not stopped: nothing to record
+
+	st := s.Stats()
+	if st.STWPauses != cycles {
+		t.Fatalf("STWPauses = %d, want %d", st.STWPauses, cycles)
+	}
+	if st.STWTotal < cycles*2*time.Millisecond || st.STWMax < 2*time.Millisecond {
+		t.Errorf("STWTotal = %v, STWMax = %v; each pause lasted at least 2ms", st.STWTotal, st.STWMax)
+	}
+	if st.STWMax > time.Second || st.STWMax > st.STWTotal {
+		t.Errorf("STWMax = %v out of bounds (STWTotal %v)", st.STWMax, st.STWTotal)
+	}
+	if st.Completed == 0 {
+		t.Errorf("no work completed between pauses: %+v", st)
+	}
+}
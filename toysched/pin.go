@@ -0,0 +1,54 @@
+package toysched
+
+import "fmt"
+
+// NewGPinned creates a runnable G that may only ever run on the P with
+// ID pID, a toy runtime.LockOSThread. Placement puts it straight on that
+// P, bypassing the policy and any cap; stealing and overflow leave it
+// there; and once queued it never passes through globalQ. If SetNumP
+// retires the P, the G is unpinned and moves like any other.
+func (s *Scheduler) NewGPinned(f func(), pID int) *G {
+	g := s.NewG(f, false)
+	g.PinnedP = pID
+	return g
+}
+
+// pinnedLocked returns the P g is pinned to, or nil if it isn't pinned.
+// It fails with ErrNoSuchP if g is pinned to a P that doesn't exist.
+// Caller must hold s.mu.
+func (s *Scheduler) pinnedLocked(g *G) (*P, error) {
+	if g.PinnedP < 0 {
+		return nil, nil
+	}
+	for _, p := range s.Ps {
+		if p.ID == g.PinnedP {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: G%d pinned to P%d", ErrNoSuchP, g.ID, g.PinnedP)
+}
+
+// takeUnpinned splits q into up to n of its unpinned Gs, oldest first,
+// and everything else, keeping order. Stealing and overflow use it so a
+// pinned G never leaves its P.
+func takeUnpinned(q []*G, n int) (taken, kept []*G) {
+	for _, g := range q {
+		if len(taken) < n && g.PinnedP < 0 {
+			taken = append(taken, g)
+		} else {
+			kept = append(kept, g)
+		}
+	}
+	return taken, kept
+}
+
+// unpinnedLen counts the Gs in p's queue that may be stolen.
+func unpinnedLen(p *P) int {
+	n := 0
+	for _, g := range p.RunQ {
+		if g.PinnedP < 0 {
+			n++
+		}
+	}
+	return n
+}
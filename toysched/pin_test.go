@@ -0,0 +1,65 @@
+package toysched
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPinnedGRunsOnlyOnItsP(t *testing.T) {
+	tests := []struct {
+		name   string
+		numM   int
+		policy StealPolicy
+		seed   int64
+	}{
+		{"steal half", 2, StealHalf, 0},
+		{"steal one, surplus Ms", 4, StealOne, 0},
+		{"seeded", 4, StealHalf, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []Option
+			if tt.seed != 0 {
+				opts = append(opts, WithSeed(tt.seed))
+			}
+			s := newDeterministic(t, 2, tt.numM, opts...)
+			s.StealPolicy = tt.policy
+			pinned := s.NewGPinned(func() {
+				for range 5 {
+					s.Yield()
+				}
+			}, 1)
+			// Queue it behind work on P1 so idle Ms try to steal from there.
+			fillP(s, s.Ps[1], 3)
+			if err := s.Enqueue(s.Ps[0], pinned); err != nil {
+				t.Fatal(err)
+			}
+			fillP(s, s.Ps[1], 3)
+			s.RunDeterministic()
+
+			if pinned.Status() != Done {
+				t.Fatalf("pinned G is %v", pinned.Status())
+			}
+			for _, e := range s.DumpTrace() {
+				if e.GID != pinned.ID {
+					continue
+				}
+				switch e.Kind {
+				case EventStart, EventResume:
+					if e.PID != 1 {
+						t.Fatalf("pinned G ran on P%d: %v", e.PID, e)
+					}
+				case EventSteal:
+					t.Fatalf("pinned G was stolen: %v", e)
+				}
+			}
+		})
+	}
+}
+
+func TestPinnedToMissingP(t *testing.T) {
+	s := newDeterministic(t, 2, 2)
+	if err := s.Enqueue(s.Ps[0], s.NewGPinned(func() {}, 5)); !errors.Is(err, ErrNoSuchP) {
+		t.Fatalf("Enqueue: err = %v, want ErrNoSuchP", err)
+	}
+}
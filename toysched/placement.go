@@ -0,0 +1,184 @@
+package toysched
+
+// Placement decides which P a newly queued G goes to. Enqueue and Submit
+// consult it under the scheduler's lock, so Place must not call back
+// into Scheduler methods that take the lock. Returning nil sends the G
+// to globalQ, where any idle M can steal it.
+type Placement interface {
+	Place(s *Scheduler, g *G) *P
+}
+
+// Option configures a Scheduler in NewScheduler.
+type Option func(*Scheduler)
+
+// WithPlacement sets the policy Enqueue and Submit use to pick a P.
+// The default is Sticky with the default threshold.
+func WithPlacement(pl Placement) Option {
+	return func(s *Scheduler) { s.placement = pl }
+}
+
+// full reports whether p holds more than threshold Gs. A zero threshold
+// means overflowThreshold, unless p has a Cap: then the P is never full
+// here, and placeLocked overflows it by capacity instead.
+func full(p *P, threshold int) bool {
+	if threshold <= 0 {
+		if p.Cap > 0 {
+			return false
+		}
+		threshold = overflowThreshold
+	}
+	return p.NumG > threshold
+}
+
+// WithLocalQueueCap gives every P (including ones added later by
+// SetNumP) a Cap of n; see P.Cap.
+func WithLocalQueueCap(n int) Option {
+	return func(s *Scheduler) { s.localCap = n }
+}
+
+// RoundRobin deals Gs to Ps in turn, skipping over full ones. If every
+// P is full the G overflows to globalQ.
+type RoundRobin struct {
+	// A P holding more than this many Gs is skipped; 0 means 5.
+	Threshold int
+
+	next int
+}
+
+func (rr *RoundRobin) Place(s *Scheduler, g *G) *P {
+	for range s.Ps {
+		p := s.Ps[rr.next%len(s.Ps)]
+		rr.next = (rr.next + 1) % len(s.Ps)
+		if !full(p, rr.Threshold) {
+			return p
+		}
+	}
+	return nil
+}
+
+// LeastLoaded puts each G on the P with the shortest run queue, or on
+// globalQ if even that one is full.
+type LeastLoaded struct {
+	// A P holding more than this many Gs is full; 0 means 5.
+	Threshold int
+}
+
+func (ll LeastLoaded) Place(s *Scheduler, g *G) *P {
+	p := s.leastLoadedLocked()
+	if full(p, ll.Threshold) {
+		return nil
+	}
+	return p
+}
+
+// Sticky keeps a G where its submitter suggests: the P passed to
+// Enqueue, or the P of the G that called Submit. With no suggestion it
+// falls back to the least-loaded P. If the chosen P is full the G
+// overflows to globalQ rather than moving elsewhere, which keeps work
+// local and leaves balancing to stealing.
+type Sticky struct {
+	// A P holding more than this many Gs is full; 0 means 5.
+	Threshold int
+}
+
+func (st Sticky) Place(s *Scheduler, g *G) *P {
+	p := g.lastP
+	if p == nil || p.retired {
+		p = s.leastLoadedLocked()
+	}
+	if full(p, st.Threshold) {
+		return nil
+	}
+	return p
+}
+
+// placeLocked queues g wherever the placement policy says, overflowing
+// to globalQ on nil. If globalQ is at GlobalQueueCap, or the P is full
+// and DisableOverflow is set, it waits for space when BlockOnFull is
+// set, or fails with ErrQueueFull. User Gs never go on the system P:
+// it is neither suggested to the policy nor accepted from it. Caller
+// must hold s.mu.
+func (s *Scheduler) placeLocked(g *G) error {
+	if s.stoppedLocked() {
+		return ErrSchedulerStopped
+	}
+	if g.lastP != nil && g.lastP == s.sysP {
+		g.lastP = nil
+	}
+	g.readyAt = s.now()
+	if g.CreatedAt.IsZero() {
+		g.CreatedAt = g.readyAt
+	}
+	g.setStatus(Runnable)
+	if pp, err := s.pinnedLocked(g); err != nil {
+		return err
+	} else if pp != nil {
+		// Pinned: straight onto its P, whatever the policy or cap.
+		pp.push(g)
+		s.wakeMs()
+		return nil
+	}
+	for {
+		var p *P
+		if !s.Centralized {
+			p = s.placement.Place(s, g)
+		}
+		if p != nil && p == s.sysP {
+			p = nil
+		}
+		local := !s.Centralized && s.DisableOverflow
+		if p != nil {
+			p = s.liveLocked(p)
+			if p.Cap <= 0 || p.NumG < p.Cap {
+				p.push(g)
+				break
+			}
+			if !local {
+				s.overflowHalfLocked(p, g)
+				break
+			}
+		} else if !local && (s.GlobalQueueCap <= 0 || len(s.globalQ) < s.GlobalQueueCap) {
+			s.globalQ = append(s.globalQ, g)
+			if s.Centralized {
+				// Not an overflow: globalQ is the only queue.
+				break
+			}
+			pid := -1
+			if g.lastP != nil {
+				pid = g.lastP.ID
+			}
+			s.emit(SchedEvent{Kind: EventOverflow, MID: -1, PID: pid, GID: g.ID})
+			break
+		}
+		if !s.BlockOnFull {
+			return ErrQueueFull
+		}
+		if s.shutdown {
+			return ErrShutdown
+		}
+		if s.stoppedLocked() {
+			return ErrSchedulerStopped
+		}
+		// Ms taking from globalQ (or, with DisableOverflow, from a P)
+		// signal space; a P may free up too, so ask the policy again.
+		s.space.Wait()
+	}
+	s.wakeMs()
+	return nil
+}
+
+// overflowHalfLocked handles g arriving at a P that is at capacity the
+// way the runtime's runqputslow does: the older half of p's queue moves
+// to globalQ, followed by g, so one move makes room for many pushes.
+// Pinned Gs are skipped and stay put.
+// GlobalQueueCap doesn't apply; the Gs were already queued.
+// Caller must hold s.mu.
+func (s *Scheduler) overflowHalfLocked(p *P, g *G) {
+	moved, kept := takeUnpinned(p.RunQ, p.NumG/2)
+	n := len(moved)
+	p.RunQ = kept
+	p.NumG -= n
+	s.globalQ = append(s.globalQ, moved...)
+	s.globalQ = append(s.globalQ, g)
+	s.emit(SchedEvent{Kind: EventOverflow, MID: -1, PID: p.ID, GID: g.ID, N: n + 1})
+}
@@ -0,0 +1,354 @@
+package toysched
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fillP queues n no-op Gs straight onto p.
+func fillP(s *Scheduler, p *P, n int) {
+	for range n {
+		p.push(s.NewG(func() {}, false))
+	}
+}
+
+func TestGlobalQueueCap(t *testing.T) {
+	tests := []struct {
+		name        string
+		cap         int
+		blockOnFull bool
+		wantErr     error
+	}{
+		{"unbounded", 0, false, nil},
+		{"room left", 3, false, nil},
+		{"full, fail fast", 2, false, ErrQueueFull},
+		{"full, block", 2, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := NewScheduler(1, 1, WithLogger(nil))
+			s.TickInterval = time.Millisecond
+			s.GlobalQueueCap = tt.cap
+			s.BlockOnFull = tt.blockOnFull
+			fillP(s, s.Ps[0], overflowThreshold+1)
+			for range 2 {
+				if err := s.Enqueue(s.Ps[0], s.NewG(func() {}, false)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			start := time.Now()
+			done := make(chan error, 1)
+			go func() {
+				_, err := s.Submit(func() {})
+				done <- err
+			}()
+			var err error
+			select {
+			case err = <-done:
+				if tt.blockOnFull {
+					t.Fatal("Submit did not wait for room in globalQ")
+				}
+			case <-time.After(20 * time.Millisecond):
+				if !tt.blockOnFull {
+					t.Fatal("Submit waited on a full globalQ")
+				}
+				// An M taking a G from globalQ makes room.
+				s.Run()
+				defer s.Stop()
+				err = <-done
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Submit: err = %v, want %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrQueueFull) && time.Since(start) > 10*time.Millisecond {
+				t.Fatalf("ErrQueueFull took %v", time.Since(start))
+			}
+			if q := s.GlobalQueued(); tt.cap > 0 && len(q) > tt.cap {
+				t.Fatalf("globalQ holds %d Gs, cap %d", len(q), tt.cap)
+			}
+		})
+	}
+}
+
+func TestBlockOnFullReleasedByStop(t *testing.T) {
+	s, _ := NewScheduler(1, 1, WithLogger(nil))
+	s.GlobalQueueCap = 1
+	s.BlockOnFull = true
+	fillP(s, s.Ps[0], overflowThreshold+1)
+	s.Enqueue(s.Ps[0], s.NewG(func() {}, false))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.Submit(func() {})
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	s.Stop()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrSchedulerStopped) {
+			t.Fatalf("Submit: err = %v, want ErrSchedulerStopped", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Submit still waiting after Stop")
+	}
+}
+
+func TestOverflowMovesOlderHalf(t *testing.T) {
+	tests := []struct {
+		name        string
+		cap         int
+		queued      int
+		pinned      int // of the queued Gs, how many (the oldest) are pinned
+		wantLocal   int
+		wantGlobalQ int
+	}{
+		{"room left", 4, 3, 0, 4, 0},
+		{"at cap", 4, 4, 0, 2, 3},
+		{"odd cap", 5, 5, 0, 3, 3},
+		{"pinned Gs stay", 4, 4, 2, 2, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, 1, 1, WithLocalQueueCap(tt.cap))
+			p := s.Ps[0]
+			fillP(s, p, tt.queued)
+			for _, g := range p.RunQ[:tt.pinned] {
+				g.PinnedP = p.ID
+			}
+			var oldest []*G
+			for _, g := range p.RunQ {
+				if g.PinnedP < 0 {
+					oldest = append(oldest, g)
+				}
+			}
+			g := s.NewG(func() {}, false)
+			if err := s.Enqueue(p, g); err != nil {
+				t.Fatal(err)
+			}
+
+			if p.NumG != tt.wantLocal || len(p.RunQ) != p.NumG {
+				t.Fatalf("P0: NumG %d, len(RunQ) %d; want %d", p.NumG, len(p.RunQ), tt.wantLocal)
+			}
+			q := s.GlobalQueued()
+			if len(q) != tt.wantGlobalQ {
+				t.Fatalf("globalQ = %v, want %d Gs", q, tt.wantGlobalQ)
+			}
+			if tt.wantGlobalQ > 0 {
+				// The oldest unpinned Gs move, in order, then the new G.
+				for i, id := range q[:len(q)-1] {
+					if id != oldest[i].ID {
+						t.Fatalf("globalQ = %v, want the oldest unpinned Gs first", q)
+					}
+				}
+				if q[len(q)-1] != g.ID {
+					t.Fatalf("globalQ = %v, want G%d last", q, g.ID)
+				}
+			}
+			if err := s.Check(); err != nil {
+				t.Fatal(err)
+			}
+			s.RunDeterministic()
+			if st := s.Stats(); st.Completed != tt.queued+1 {
+				t.Fatalf("Completed = %d, want %d", st.Completed, tt.queued+1)
+			}
+		})
+	}
+}
+
+func TestCentralizedDequeuesOnlyFromGlobalQ(t *testing.T) {
+	tests := []struct {
+		name        string
+		centralized bool
+		yield       bool
+	}{
+		{"centralized", true, false},
+		{"centralized, Gs yield", true, true},
+		// Per-P queues: most Gs run without touching globalQ.
+		{"per-P", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, 3, 3)
+			s.Centralized = tt.centralized
+			for i := range 12 {
+				s.Enqueue(s.Ps[i%3], s.NewG(func() {
+					if tt.yield {
+						s.Yield()
+					}
+				}, false))
+			}
+			for s.Step() {
+				if !tt.centralized {
+					continue
+				}
+				for _, p := range s.Ps {
+					if p.NumG != 0 {
+						t.Fatalf("P%d holds %d Gs in centralized mode", p.ID, p.NumG)
+					}
+				}
+			}
+			if st := s.Stats(); st.Completed != 12 {
+				t.Fatalf("Completed = %d, want 12", st.Completed)
+			}
+
+			// Every G an M runs is one it just took from globalQ.
+			last := make(map[int]SchedEvent) // by M
+			local := 0
+			for _, e := range s.DumpTrace() {
+				if e.Kind == EventStart || e.Kind == EventResume {
+					if prev := last[e.MID]; prev.Kind != EventSteal || prev.From != -1 || prev.GID != e.GID {
+						local++
+					}
+				}
+				if e.MID >= 0 {
+					last[e.MID] = e
+				}
+			}
+			if tt.centralized && local != 0 {
+				t.Fatalf("%d Gs ran without being taken from globalQ", local)
+			}
+			if !tt.centralized && local == 0 {
+				t.Fatal("every G went through globalQ with per-P queues")
+			}
+		})
+	}
+}
+
+func TestDisableOverflow(t *testing.T) {
+	tests := []struct {
+		name            string
+		cap             int // 0: the default threshold applies
+		disableOverflow bool
+		wantErr         error
+		wantGlobalQ     int
+	}{
+		{"cap, overflow on", 3, false, nil, 2},
+		{"cap, overflow off", 3, true, ErrQueueFull, 0},
+		{"threshold, overflow on", 0, false, nil, 1},
+		{"threshold, overflow off", 0, true, ErrQueueFull, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, 1, 1, WithLocalQueueCap(tt.cap))
+			s.DisableOverflow = tt.disableOverflow
+			room := tt.cap
+			if room == 0 {
+				room = overflowThreshold + 1
+			}
+			for i := range room {
+				if err := s.Enqueue(s.Ps[0], s.NewG(func() {}, false)); err != nil {
+					t.Fatalf("Enqueue %d: %v", i, err)
+				}
+			}
+			_, err := s.Submit(func() {})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Submit past capacity: err = %v, want %v", err, tt.wantErr)
+			}
+			if got := len(s.GlobalQueued()); got != tt.wantGlobalQ {
+				t.Fatalf("globalQ holds %d Gs, want %d", got, tt.wantGlobalQ)
+			}
+			if err := s.Check(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestDisableOverflowBlocksUntilSpace(t *testing.T) {
+	s, _ := NewScheduler(1, 1, WithLogger(nil), WithLocalQueueCap(2))
+	s.TickInterval = time.Millisecond
+	s.DisableOverflow = true
+	s.BlockOnFull = true
+	release := make(chan struct{})
+	for range 2 {
+		s.Enqueue(s.Ps[0], s.NewG(func() { <-release }, false))
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.Submit(func() {})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("Submit to a full P returned %v before any space", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	s.Run()
+	defer s.Stop()
+	close(release)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Submit still waiting once the P drained")
+	}
+	if got := s.GlobalQueued(); len(got) != 0 {
+		t.Fatalf("globalQ = %v, want empty", got)
+	}
+}
+
+func TestPlacementPolicies(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      Placement
+		preload     []int // Gs already queued on each P
+		onto        []int // P suggested for each new G; -1 submits with none
+		wantNumG    []int
+		wantGlobalQ int
+	}{
+		{"round robin deals in turn", &RoundRobin{Threshold: 2}, []int{0, 0}, []int{0, 0, 0, 0}, []int{2, 2}, 0},
+		{"round robin skips a full P", &RoundRobin{Threshold: 2}, []int{3, 0}, []int{0, 0, 0}, []int{3, 3}, 0},
+		{"round robin, every P full", &RoundRobin{Threshold: 2}, []int{3, 3}, []int{0, 1}, []int{3, 3}, 2},
+		{"round robin, default threshold", &RoundRobin{}, []int{6, 0}, []int{0, 0}, []int{6, 2}, 0},
+		{"least loaded evens out", LeastLoaded{Threshold: 2}, []int{2, 0}, []int{0, 0}, []int{2, 2}, 0},
+		{"least loaded, every P full", LeastLoaded{Threshold: 2}, []int{3, 3}, []int{0}, []int{3, 3}, 1},
+		{"sticky keeps the suggested P", Sticky{Threshold: 2}, []int{0, 0}, []int{1, 1, 1}, []int{0, 3}, 0},
+		{"sticky overflows, doesn't move", Sticky{Threshold: 2}, []int{3, 0}, []int{0}, []int{3, 0}, 1},
+		{"sticky without a suggestion", Sticky{Threshold: 2}, []int{1, 0}, []int{-1}, []int{1, 1}, 0},
+		{"sticky, default threshold", Sticky{}, []int{5, 0}, []int{0, 0}, []int{6, 0}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, len(tt.preload), len(tt.preload), WithPlacement(tt.policy))
+			total := 0
+			for i, n := range tt.preload {
+				fillP(s, s.Ps[i], n)
+				total += n
+			}
+			for _, i := range tt.onto {
+				var err error
+				if i < 0 {
+					_, err = s.Submit(func() {})
+				} else {
+					err = s.Enqueue(s.Ps[i], s.NewG(func() {}, false))
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				total++
+			}
+
+			for i, p := range s.Ps {
+				if p.NumG != tt.wantNumG[i] {
+					t.Errorf("P%d: NumG = %d, want %d", p.ID, p.NumG, tt.wantNumG[i])
+				}
+			}
+			if q := s.GlobalQueued(); len(q) != tt.wantGlobalQ {
+				t.Errorf("globalQ = %v, want %d Gs", q, tt.wantGlobalQ)
+			}
+			if err := s.Check(); err != nil {
+				t.Fatal(err)
+			}
+			s.RunDeterministic()
+			if st := s.Stats(); st.Completed != total {
+				t.Fatalf("Completed = %d, want %d", st.Completed, total)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package toysched
+
+import "time"
+
+// NewGPreemptible creates a G whose work is the continuation step,
+// called over and over until it reports done. Once the G has run for
+// its TimeBudget in one go, it is preempted at the next step boundary:
+// requeued at the tail of its P so the M moves on to other Gs, and
+// resumed later by calling step again. A budget of 0 disables
+// preemption. Like the runtime's, preemption waits for a safe point, so
+// a single step that never returns can't be preempted. The budget is
+// measured on the scheduler's clock, so it never expires in
+// deterministic mode.
+func (s *Scheduler) NewGPreemptible(step func() (done bool), budget time.Duration) *G {
+	g := s.NewG(nil, false)
+	g.TimeBudget = budget
+	g.Func = func() {
+		for !step() {
+			if g.TimeBudget > 0 && s.now().Sub(g.sliceStart) >= g.TimeBudget {
+				g.preempted = true
+				g.setStatus(Runnable)
+				g.park()
+			}
+		}
+	}
+	return g
+}
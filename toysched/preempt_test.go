@@ -0,0 +1,57 @@
+package toysched
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeBudgetLetsShortGRun(t *testing.T) {
+	tests := []struct {
+		name           string
+		budget         time.Duration
+		wantShortFirst bool
+	}{
+		{"no budget", 0, false},
+		{"5ms budget", 5 * time.Millisecond, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := NewScheduler(1, 1, WithLogger(nil))
+			s.TickInterval = time.Millisecond
+			steps := 0
+			long := s.NewGPreemptible(func() bool {
+				spin(time.Millisecond)
+				steps++
+				return steps == 40
+			}, tt.budget)
+			short := s.NewG(func() {}, false)
+			s.Enqueue(s.Ps[0], long)
+			s.Enqueue(s.Ps[0], short)
+			s.RunAll()
+
+			if long.Status() != Done || short.Status() != Done {
+				t.Fatalf("long G %v, short G %v", long.Status(), short.Status())
+			}
+			if got := short.FinishedAt.Before(long.FinishedAt); got != tt.wantShortFirst {
+				t.Fatalf("short G finished first: %v, want %v", got, tt.wantShortFirst)
+			}
+			if got := s.Stats().Preemptions > 0; got != tt.wantShortFirst {
+				t.Fatalf("Preemptions = %d", s.Stats().Preemptions)
+			}
+		})
+	}
+}
+
+func TestTimeBudgetNeverExpiresOnVirtualClock(t *testing.T) {
+	s := newDeterministic(t, 1, 1)
+	steps := 0
+	g := s.NewGPreemptible(func() bool {
+		steps++
+		return steps == 5
+	}, time.Nanosecond)
+	s.Enqueue(s.Ps[0], g)
+	s.RunDeterministic()
+	if g.Status() != Done || s.Stats().Preemptions != 0 {
+		t.Fatalf("G is %v after %d preemptions", g.Status(), s.Stats().Preemptions)
+	}
+}
@@ -0,0 +1,103 @@
+package toysched
+
+import (
+	"errors"
+	"slices"
+)
+
+// SetNumP changes the number of Ps while the scheduler runs, like
+// runtime.GOMAXPROCS. Growing adds fresh Ps and offers them to idle Ms.
+// Shrinking retires the highest-numbered Ps: their queued Gs move to the
+// surviving Ps, and an M still holding a retired P drops it on its next
+// round. No G is lost either way.
+func (s *Scheduler) SetNumP(n int) error {
+	if n <= 0 {
+		return errors.New("toysched: numP must be positive")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old := len(s.Ps)
+	if n == old {
+		return nil
+	}
+
+	if n > old {
+		for i := old; i < n; i++ {
+			id := i
+			if s.sysP != nil && id >= s.sysP.ID {
+				// Keep clear of the system P's ID.
+				id++
+			}
+			s.Ps = append(s.Ps, &P{ID: id, RunQ: make([]*G, 0), Cap: s.localCap})
+		}
+	} else {
+		retired := s.Ps[n:]
+		s.Ps = slices.Clip(s.Ps[:n])
+		for _, p := range retired {
+			p.retired = true
+		}
+		for _, p := range retired {
+			s.migrateLocked(p)
+		}
+	}
+
+	// Rebuild availPs sized for the new count, minus retired Ps.
+	avail := make(chan *P, n)
+	for len(s.availPs) > 0 {
+		if p := <-s.availPs; !p.retired {
+			avail <- p
+		}
+	}
+	for _, p := range s.Ps[min(old, n):] {
+		avail <- p
+	}
+	s.availPs = avail
+	s.wakeMs()
+	return nil
+}
+
+// leastLoadedLocked returns the live P with the shortest run queue.
+// Caller must hold s.mu.
+func (s *Scheduler) leastLoadedLocked() *P {
+	var target *P
+	for _, p := range s.Ps {
+		if target == nil || p.NumG < target.NumG {
+			target = p
+		}
+	}
+	return target
+}
+
+// liveLocked returns p, or the least-loaded live P if p has been retired.
+// Caller must hold s.mu.
+func (s *Scheduler) liveLocked(p *P) *P {
+	if p.retired {
+		return s.leastLoadedLocked()
+	}
+	return p
+}
+
+// releasePLocked hands p back to availPs, or, if p has been retired,
+// moves anything still queued on it to live Ps instead.
+// Caller must hold s.mu.
+func (s *Scheduler) releasePLocked(p *P) {
+	if p.retired {
+		s.migrateLocked(p)
+		return
+	}
+	s.availPs <- p
+}
+
+// migrateLocked moves every G queued on the retired p to live Ps,
+// unpinning any that were pinned to it. Caller must hold s.mu.
+func (s *Scheduler) migrateLocked(p *P) {
+	for _, g := range p.RunQ {
+		if g.PinnedP == p.ID {
+			g.PinnedP = -1
+		}
+		s.leastLoadedLocked().push(g)
+	}
+	p.RunQ = p.RunQ[:0]
+	p.NumG = 0
+}
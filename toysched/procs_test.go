@@ -0,0 +1,116 @@
+package toysched
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSetNumP(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    int
+		to      int
+		queued  int // Gs queued on each P beforehand
+		wantErr bool
+	}{
+		{"grow", 2, 4, 2, false},
+		{"shrink migrates queues", 4, 1, 3, false},
+		{"same size", 3, 3, 1, false},
+		{"zero", 2, 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, tt.from, tt.from)
+			for _, p := range s.Ps {
+				for range tt.queued {
+					p.push(s.NewG(func() {}, false))
+				}
+			}
+			err := s.SetNumP(tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetNumP(%d): err = %v", tt.to, err)
+			}
+			if err != nil {
+				return
+			}
+			if len(s.Ps) != tt.to {
+				t.Fatalf("have %d Ps, want %d", len(s.Ps), tt.to)
+			}
+			queued := 0
+			for _, p := range s.Ps {
+				queued += p.NumG
+			}
+			if want := tt.from * tt.queued; queued != want {
+				t.Fatalf("%d Gs queued after resize, want %d", queued, want)
+			}
+			s.RunDeterministic()
+			if err := s.Check(); err != nil {
+				t.Fatal(err)
+			}
+			for _, m := range s.Ms {
+				if m.P != nil && m.P.retired {
+					t.Fatalf("M%d still holds retired P%d", m.ID, m.P.ID)
+				}
+			}
+			if st := s.Stats(); st.Completed != tt.from*tt.queued {
+				t.Fatalf("Completed = %d, want %d", st.Completed, tt.from*tt.queued)
+			}
+		})
+	}
+}
+
+func TestSetNumPWhileGsFlow(t *testing.T) {
+	s, _ := NewScheduler(2, 4, WithLogger(nil))
+	s.TickInterval = time.Millisecond
+	s.ParkCooldown = time.Millisecond
+	s.Run()
+	defer s.Stop()
+
+	const perPhase = 200
+	var mu sync.Mutex
+	var gs []*G
+	submit := func() {
+		for range perPhase {
+			g, err := s.Submit(func() { time.Sleep(10 * time.Microsecond) })
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			gs = append(gs, g)
+			mu.Unlock()
+		}
+	}
+	for _, n := range []int{4, 1, 3} {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			submit()
+		}()
+		time.Sleep(2 * time.Millisecond)
+		if err := s.SetNumP(n); err != nil {
+			t.Fatal(err)
+		}
+		wg.Wait()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.WaitIdle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range gs {
+		if g.Status() != Done {
+			t.Fatalf("G%d is %v, want Done", g.ID, g.Status())
+		}
+	}
+	if st := s.Stats(); st.Completed != 3*perPhase {
+		t.Fatalf("Completed = %d, want %d", st.Completed, 3*perPhase)
+	}
+	if err := s.Check(); err != nil {
+		t.Fatal(err)
+	}
+}
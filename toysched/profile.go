@@ -0,0 +1,29 @@
+package toysched
+
+import (
+	"errors"
+	"os"
+	"runtime/pprof"
+)
+
+// RunWithProfile writes a CPU profile of body, run against s, to path,
+// for `go tool pprof`. For small Gs expect most samples in the toy's
+// own bookkeeping (locking, the channel handoffs between Ms and Gs,
+// goroutine ID lookups) rather than in the Gs' work. body decides
+// whether to Run, Step or just submit. The profile covers the whole
+// process while it runs, and only one CPU profile can be active at once.
+func (s *Scheduler) RunWithProfile(path string, body func(*Scheduler)) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, f.Close())
+	}()
+	if err := pprof.StartCPUProfile(f); err != nil {
+		return err
+	}
+	defer pprof.StopCPUProfile()
+	body(s)
+	return nil
+}
@@ -0,0 +1,73 @@
+package toysched
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func profiledRun(s *Scheduler) {
+	for range 200 {
+		s.Submit(func() { spin(50 * time.Microsecond) })
+	}
+	s.RunAll()
+}
+
+func TestRunWithProfile(t *testing.T) {
+	s, _ := NewScheduler(2, 2, WithLogger(nil))
+	path := filepath.Join(t.TempDir(), "cpu.pprof")
+	if err := s.RunWithProfile(path, profiledRun); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) == 0 {
+		t.Fatal("profile is empty")
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("profile is not gzip-compressed: %v", err)
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil || len(raw) == 0 {
+		t.Fatalf("profile payload: %d bytes, %v", len(raw), err)
+	}
+
+	gobin, err := exec.LookPath("go")
+	if err != nil || testing.Short() {
+		return
+	}
+	if out, err := exec.Command(gobin, "tool", "pprof", "-raw", path).CombinedOutput(); err != nil {
+		t.Fatalf("go tool pprof: %v\n%s", err, out)
+	}
+}
+
+func TestRunWithProfileBadPath(t *testing.T) {
+	s, _ := NewScheduler(1, 1, WithLogger(nil))
+	called := false
+	path := filepath.Join(t.TempDir(), "missing", "cpu.pprof")
+	if err := s.RunWithProfile(path, func(*Scheduler) { called = true }); err == nil {
+		t.Fatal("RunWithProfile into a missing directory succeeded")
+	}
+	if called {
+		t.Error("body ran without a profile")
+	}
+}
+
+func BenchmarkRunWithProfile(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "cpu.pprof")
+	for b.Loop() {
+		s, _ := NewScheduler(2, 2, WithLogger(nil))
+		if err := s.RunWithProfile(path, profiledRun); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
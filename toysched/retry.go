@@ -0,0 +1,23 @@
+package toysched
+
+import "time"
+
+// RetryPolicy says how a G is re-run after its function panics or, for
+// NewGErr, returns an error. The zero value never retries.
+type RetryPolicy struct {
+	// Total runs allowed, the first included. The G is Failed once the
+	// last one fails. 0 or 1 means a single run.
+	MaxAttempts int
+	// How long the G sleeps (see SleepG) before each retry. 0 just
+	// yields, requeueing the G behind whatever else is on its P.
+	Backoff time.Duration
+}
+
+// NewGErr creates a runnable G whose function reports failure by
+// returning an error. The G fails on an error just as on a panic, so
+// its Retry policy applies to both.
+func (s *Scheduler) NewGErr(f func() error) *G {
+	g := s.NewG(nil, false)
+	g.errFunc = f
+	return g
+}
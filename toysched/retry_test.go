@@ -0,0 +1,98 @@
+package toysched
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var errFlaky = errors.New("flaky")
+
+func TestRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int // runs that fail before one succeeds
+		panics       bool
+		policy       RetryPolicy
+		wantStatus   GStatus
+		wantAttempts int
+	}{
+		{"fails twice then succeeds", 2, false, RetryPolicy{MaxAttempts: 3}, Done, 3},
+		{"panics twice then succeeds", 2, true, RetryPolicy{MaxAttempts: 3}, Done, 3},
+		{"out of attempts", 5, false, RetryPolicy{MaxAttempts: 3}, Failed, 3},
+		{"no retry", 1, false, RetryPolicy{}, Failed, 1},
+		{"with backoff", 2, false, RetryPolicy{MaxAttempts: 4, Backoff: 3 * time.Second}, Done, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, 1, 1)
+			runs := 0
+			g := s.NewGErr(func() error {
+				runs++
+				if runs > tt.failures {
+					return nil
+				}
+				if tt.panics {
+					panic(errFlaky)
+				}
+				return errFlaky
+			})
+			g.Retry = tt.policy
+			s.Enqueue(s.Ps[0], g)
+			start := s.now()
+			s.RunDeterministic()
+
+			if g.Status() != tt.wantStatus || g.Attempts != tt.wantAttempts {
+				t.Fatalf("G is %v after %d attempts, want %v after %d", g.Status(), g.Attempts, tt.wantStatus, tt.wantAttempts)
+			}
+			if tt.wantStatus == Failed && g.Err == nil {
+				t.Fatal("failed G has no Err")
+			}
+			if tt.wantStatus == Done && g.Result != nil {
+				t.Fatalf("Result = %v after a successful attempt", g.Result)
+			}
+			if b := tt.policy.Backoff; b > 0 {
+				if waited := s.now().Sub(start); waited < time.Duration(tt.failures)*b {
+					t.Fatalf("finished %v after start, want at least %v of backoff", waited, time.Duration(tt.failures)*b)
+				}
+			}
+		})
+	}
+}
+
+func TestSubmitErrStoresResult(t *testing.T) {
+	s, _ := NewScheduler(2, 2, WithLogger(nil))
+	s.TickInterval = time.Millisecond
+	s.Run()
+	defer s.Stop()
+
+	errs := []error{nil, errFlaky, nil, errors.New("boom"), nil}
+	var gs []*G
+	for _, err := range errs {
+		g, serr := s.SubmitErr(func() error { return err })
+		if serr != nil {
+			t.Fatal(serr)
+		}
+		gs = append(gs, g)
+	}
+	// The plain func() API still works alongside.
+	plain, _ := s.Submit(func() {})
+
+	for i, g := range gs {
+		g.Wait()
+		if g.Result != errs[i] {
+			t.Errorf("G%d Result = %v, want %v", g.ID, g.Result, errs[i])
+		}
+		want := Done
+		if errs[i] != nil {
+			want = Failed
+		}
+		if g.Status() != want {
+			t.Errorf("G%d is %v, want %v", g.ID, g.Status(), want)
+		}
+	}
+	plain.Wait()
+	if plain.Status() != Done || plain.Result != nil {
+		t.Fatalf("plain G is %v with Result %v", plain.Status(), plain.Result)
+	}
+}
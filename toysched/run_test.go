@@ -0,0 +1,117 @@
+package toysched_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"toysched"
+	"toysched/schedtest"
+)
+
+func TestRunContextCancelStopsScheduler(t *testing.T) {
+	schedtest.AssertNoLeakedGoroutines(t, func() {
+		s, _ := toysched.NewScheduler(2, 2)
+		s.SetConsole(nil)
+		s.SysmonThreshold = time.Millisecond
+		s.AllParked()
+		for range 10 {
+			s.Submit(func() {})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := s.RunContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("RunContext: err = %v, want DeadlineExceeded", err)
+		}
+		if _, err := s.Submit(func() {}); !errors.Is(err, toysched.ErrSchedulerStopped) {
+			t.Errorf("Submit after cancel: err = %v, want ErrSchedulerStopped", err)
+		}
+	})
+}
+
+func TestRunContextCancelReachesRunningGs(t *testing.T) {
+	s, _ := toysched.NewScheduler(2, 2, toysched.WithLogger(nil))
+	s.TickInterval = time.Millisecond
+	var gs []*toysched.G
+	var bailed atomic.Int32
+	for range 20 {
+		g := s.NewGCtx(func(ctx context.Context) {
+			select {
+			case <-ctx.Done():
+				bailed.Add(1)
+			case <-time.After(20 * time.Millisecond):
+			}
+		})
+		if err := s.Enqueue(s.Ps[len(gs)%2], g); err != nil {
+			t.Fatal(err)
+		}
+		gs = append(gs, g)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	done := make(chan error)
+	go func() { done <- s.RunContext(ctx) }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("RunContext: err = %v, want DeadlineExceeded", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunContext did not return after cancellation")
+	}
+
+	ran := 0
+	for _, g := range gs {
+		if g.Status() == toysched.Done {
+			ran++
+		}
+	}
+	if ran == 0 || ran == len(gs) {
+		t.Errorf("%d of %d Gs ran, want some but not all", ran, len(gs))
+	}
+	if bailed.Load() == 0 {
+		t.Error("no running G saw ctx.Done()")
+	}
+}
+
+func TestCloseLeavesNoGoroutines(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(s *toysched.Scheduler)
+	}{
+		{"polling", func(*toysched.Scheduler) {}},
+		{"event-driven", func(s *toysched.Scheduler) { s.EventDriven = true }},
+		{"adaptive tick", func(s *toysched.Scheduler) { s.AdaptiveTick = true }},
+		{"sysmon", func(s *toysched.Scheduler) { s.SysmonThreshold = time.Millisecond }},
+		{"watchdog", func(s *toysched.Scheduler) { s.OnDeadlock(func(int) {}) }},
+		{"spinning cap", func(s *toysched.Scheduler) { s.MaxSpinning = 1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedtest.AssertNoLeakedGoroutines(t, func() {
+				s, _ := toysched.NewScheduler(2, 3, toysched.WithLogger(nil))
+				s.TickInterval = time.Millisecond
+				tt.setup(s)
+				s.Run()
+				var gs []*toysched.G
+				for range 20 {
+					g, err := s.Submit(func() {})
+					if err != nil {
+						t.Fatal(err)
+					}
+					gs = append(gs, g)
+				}
+				for _, g := range gs {
+					g.Wait()
+				}
+				if err := s.Close(); err != nil {
+					t.Error(err)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,30 @@
+// Package schedtest holds test helpers for code built on toysched.
+package schedtest
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+// leakSettle is how long AssertNoLeakedGoroutines gives goroutines that
+// are on their way out to finish before it counts them as leaked.
+const leakSettle = time.Second
+
+// AssertNoLeakedGoroutines fails t if fn leaves more goroutines behind
+// than were running before it, e.g. a scheduler that was Run but never
+// stopped. Goroutines get up to a second to exit before the check fails.
+func AssertNoLeakedGoroutines(t testing.TB, fn func()) {
+	t.Helper()
+	before := runtime.NumGoroutine()
+	fn()
+	deadline := time.Now().Add(leakSettle)
+	after := runtime.NumGoroutine()
+	for after > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		after = runtime.NumGoroutine()
+	}
+	if after > before {
+		t.Errorf("%d goroutine(s) leaked: %d before, %d after", after-before, before, after)
+	}
+}
@@ -0,0 +1,53 @@
+package schedtest
+
+import (
+	"testing"
+
+	"toysched"
+)
+
+// recorder is a testing.TB that notes failures instead of reporting them.
+type recorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recorder) Errorf(string, ...any) { r.failed = true }
+
+func TestAssertNoLeakedGoroutines(t *testing.T) {
+	var leaked *toysched.Scheduler
+	t.Cleanup(func() {
+		if leaked != nil {
+			leaked.Stop()
+		}
+	})
+	tests := []struct {
+		name     string
+		fn       func()
+		wantFail bool
+	}{
+		{"closed", func() {
+			s, _ := toysched.NewScheduler(2, 2)
+			s.SetConsole(nil)
+			s.Run()
+			if err := s.Close(); err != nil {
+				t.Error(err)
+			}
+		}, false},
+		{"never stopped", func() {
+			s, _ := toysched.NewScheduler(2, 2)
+			s.SetConsole(nil)
+			s.Run()
+			leaked = s
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{TB: t}
+			AssertNoLeakedGoroutines(r, tt.fn)
+			if r.failed != tt.wantFail {
+				t.Fatalf("failed = %v, want %v", r.failed, tt.wantFail)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package toysched
+
+import "context"
+
+// ShutdownMode selects how Shutdown treats work that is still queued.
+type ShutdownMode int
+
+const (
+	// Drain runs every queued G to completion, then stops the Ms.
+	Drain ShutdownMode = iota
+	// Abort stops the Ms at their next round, leaving queued Gs unrun.
+	Abort
+)
+
+// Shutdown stops the scheduler. From the moment it is called, Submit
+// refuses new Gs with ErrShutdown. With Drain it waits until the
+// scheduler is idle before stopping the Ms (so it needs a running
+// scheduler to return); with Abort it stops them straight away. It
+// returns the number of queued Gs left unrun, which is 0 for Drain.
+func (s *Scheduler) Shutdown(mode ShutdownMode) int {
+	s.mu.Lock()
+	s.shutdown = true
+	// Release anyone waiting for globalQ space.
+	s.space.Broadcast()
+	s.mu.Unlock()
+
+	if mode == Drain {
+		s.WaitIdle(context.Background())
+	}
+	s.Stop()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.globalQ)
+	for _, p := range s.queuesLocked() {
+		n += p.NumG
+	}
+	return n
+}
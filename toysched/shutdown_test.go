@@ -0,0 +1,59 @@
+package toysched
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestShutdown(t *testing.T) {
+	tests := []struct {
+		name          string
+		mode          ShutdownMode
+		wantAbandoned bool
+	}{
+		{"drain", Drain, false},
+		{"abort", Abort, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := NewScheduler(2, 2, WithLogger(nil))
+			s.TickInterval = time.Millisecond
+			var gs []*G
+			for range 50 {
+				g, err := s.Submit(func() { time.Sleep(time.Millisecond) })
+				if err != nil {
+					t.Fatal(err)
+				}
+				gs = append(gs, g)
+			}
+			s.Run()
+
+			start := time.Now()
+			abandoned := s.Shutdown(tt.mode)
+			took := time.Since(start)
+			if _, err := s.Submit(func() {}); !errors.Is(err, ErrShutdown) {
+				t.Errorf("Submit after Shutdown: err = %v, want ErrShutdown", err)
+			}
+
+			done := 0
+			for _, g := range gs {
+				if g.Status() == Done {
+					done++
+				}
+			}
+			if !tt.wantAbandoned {
+				if abandoned != 0 || done != len(gs) {
+					t.Fatalf("abandoned %d, %d of %d Gs done", abandoned, done, len(gs))
+				}
+				return
+			}
+			if abandoned == 0 || abandoned+done != len(gs) {
+				t.Fatalf("abandoned %d with %d of %d Gs done", abandoned, done, len(gs))
+			}
+			if took > time.Second {
+				t.Fatalf("Abort took %v", took)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package toysched
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// A timer on the deterministic mode's virtual clock.
+type vtimer struct {
+	when time.Time
+	f    func()
+}
+
+// WithDeterministic puts the scheduler in deterministic mode, to be
+// driven by Step or RunDeterministic rather than Run. Its clock is
+// virtual from the start, beginning at the Unix epoch and advancing
+// TickInterval per full round of Ms, so every timestamp, including those
+// of Gs queued before the first Step, comes from the same clock.
+func WithDeterministic() Option {
+	return func(s *Scheduler) {
+		s.deterministic = true
+		s.clock = time.Unix(0, 0)
+	}
+}
+
+// Step advances the scheduler by one logical tick on the calling
+// goroutine: the next M in round-robin order (a random one when seeded)
+// runs one scheduling round synchronously. Time is virtual, so the same
+// setup always produces the same output. It reports whether any work
+// remains. It panics unless the scheduler was made WithDeterministic.
+func (s *Scheduler) Step() bool {
+	if !s.deterministic {
+		panic("toysched: Step needs a scheduler made WithDeterministic")
+	}
+	if len(s.Ms) == 0 {
+		return false
+	}
+	if s.startedAt.IsZero() {
+		s.startedAt = time.Now()
+	}
+	if s.nextM == 0 {
+		s.clock = s.clock.Add(s.TickInterval)
+		s.fireTimers()
+		if s.SysmonThreshold > 0 {
+			s.retake()
+		}
+		if s.DeadlockTicks > 0 || s.onDeadlock != nil {
+			s.checkDeadlock()
+		}
+	}
+
+	s.mu.Lock()
+	m := s.Ms[s.nextM%len(s.Ms)]
+	if s.rng != nil {
+		m = s.Ms[s.rng.Intn(len(s.Ms))]
+	}
+	s.nextM = (s.nextM + 1) % len(s.Ms)
+	s.mu.Unlock()
+	m.scheduleOnce(s)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hasWorkLocked()
+}
+
+// RunDeterministic steps the scheduler until no work remains.
+func (s *Scheduler) RunDeterministic() {
+	s.printf("=== Starting Toy Schedule ===\n")
+	for s.Step() {
+	}
+}
+
+// now is the scheduler's clock: virtual in deterministic mode.
+func (s *Scheduler) now() time.Time {
+	if s.deterministic {
+		return s.clock
+	}
+	return time.Now()
+}
+
+// afterFunc runs f after d, on the virtual clock in deterministic mode.
+func (s *Scheduler) afterFunc(d time.Duration, f func()) {
+	if !s.deterministic {
+		time.AfterFunc(d, f)
+		return
+	}
+	s.vtimers = append(s.vtimers, vtimer{when: s.clock.Add(d), f: f})
+	sort.SliceStable(s.vtimers, func(i, j int) bool {
+		return s.vtimers[i].when.Before(s.vtimers[j].when)
+	})
+}
+
+// fireTimers runs every virtual timer that is due.
+func (s *Scheduler) fireTimers() {
+	for len(s.vtimers) > 0 && !s.vtimers[0].when.After(s.clock) {
+		t := s.vtimers[0]
+		s.vtimers = s.vtimers[1:]
+		t.f()
+	}
+}
+
+// hasWorkLocked reports whether anything can still make progress:
+// queued Gs, a woken blocked G, or a pending timer. Caller must hold s.mu.
+func (s *Scheduler) hasWorkLocked() bool {
+	if len(s.globalQ) > 0 || len(s.vtimers) > 0 {
+		return true
+	}
+	for _, p := range s.queuesLocked() {
+		if p.NumG > 0 {
+			return true
+		}
+	}
+	for _, m := range s.Ms {
+		if m.G != nil && len(m.G.blockChan) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// WithSeed makes scheduling decisions pseudo-random but reproducible:
+// Step picks which M runs next, and a stealing M picks its victim, from
+// a rand.Rand seeded with seed. Under Step/RunDeterministic a given seed
+// always replays the same interleaving, so a failing seed can be
+// bisected and replayed; running many seeds explores many
+// interleavings.
+func WithSeed(seed int64) Option {
+	return func(s *Scheduler) { s.rng = rand.New(rand.NewSource(seed)) }
+}
+
+// NewSchedulerSeeded is NewScheduler with WithSeed(seed).
+func NewSchedulerSeeded(numP, numM int, seed int64, opts ...Option) (*Scheduler, error) {
+	return NewScheduler(numP, numM, append(opts, WithSeed(seed))...)
+}
@@ -0,0 +1,148 @@
+package toysched
+
+import (
+	"testing"
+	"time"
+)
+
+// newDeterministic returns a scheduler driven by Step that logs nothing
+// unless opts include a WithLogger.
+func newDeterministic(t *testing.T, numP, numM int, opts ...Option) *Scheduler {
+	t.Helper()
+	s, err := NewScheduler(numP, numM, append([]Option{WithLogger(nil), WithDeterministic()}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestDeterministicLatenciesUseVirtualClock(t *testing.T) {
+	s := newDeterministic(t, 1, 1)
+	var gs []*G
+	for range 4 {
+		g := s.NewG(func() {}, false)
+		if err := s.Enqueue(s.Ps[0], g); err != nil {
+			t.Fatal(err)
+		}
+		gs = append(gs, g)
+	}
+	s.RunDeterministic()
+
+	for i, g := range gs {
+		if g.CreatedAt.Before(time.Unix(0, 0)) || g.CreatedAt.After(time.Unix(60, 0)) {
+			t.Errorf("G%d CreatedAt = %v, want on the virtual clock", g.ID, g.CreatedAt)
+		}
+		want := time.Duration(i+1) * s.TickInterval
+		if got := g.WaitLatency(); got != want {
+			t.Errorf("G%d WaitLatency = %v, want %v", g.ID, got, want)
+		}
+	}
+	if r := s.LatencyReport(); r.Count != 4 || r.P50 <= 0 || r.P99 > time.Minute {
+		t.Errorf("LatencyReport = %+v", r)
+	}
+}
+
+func TestDeterministicReplaysIdentically(t *testing.T) {
+	trace := func() []SchedEvent {
+		s := newDeterministic(t, 2, 3, WithSeed(7))
+		for i := range 12 {
+			s.Enqueue(s.Ps[i%2], s.NewG(func() { s.Yield() }, false))
+		}
+		s.Enqueue(s.Ps[0], s.NewGBlocking(func() {}, 3*s.TickInterval))
+		s.RunDeterministic()
+		return s.DumpTrace()
+	}
+	a, b := trace(), trace()
+	if len(a) == 0 || len(a) != len(b) {
+		t.Fatalf("traces have %d and %d events", len(a), len(b))
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("event %d differs: %+v vs %+v", i, a[i], b[i])
+		}
+	}
+}
+
+func TestDeterministicModeIsChosenUpFront(t *testing.T) {
+	mustPanic := func(name string, f func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s did not panic", name)
+			}
+		}()
+		f()
+	}
+	s, _ := NewScheduler(1, 1)
+	mustPanic("Step without WithDeterministic", func() { s.Step() })
+	d := newDeterministic(t, 1, 1)
+	mustPanic("Run WithDeterministic", d.Run)
+}
+
+func TestDeterministicStarvationGuardBeforeFirstStep(t *testing.T) {
+	s := newDeterministic(t, 1, 1)
+	s.StarvationThreshold = 3 * s.TickInterval
+	low := s.NewGWithPriority(func() {}, 0)
+	s.Enqueue(s.Ps[0], low)
+	for range 8 {
+		s.Enqueue(s.Ps[0], s.NewGWithPriority(func() {
+			for range 4 {
+				s.Yield()
+			}
+		}, 5))
+	}
+	for low.Status() != Done {
+		if !s.Step() {
+			t.Fatal("ran out of work before the low-priority G finished")
+		}
+	}
+	if st := s.Stats(); st.Promotions == 0 || st.Completed == 9 {
+		t.Fatalf("low-priority G was not promoted: %+v", st)
+	}
+}
+
+func TestSeededPingPongNeverDeadlocks(t *testing.T) {
+	seeds := 1000
+	if testing.Short() {
+		seeds = 100
+	}
+	orders := make(map[string]bool)
+	for seed := range int64(seeds) {
+		s, err := NewSchedulerSeeded(2, 3, seed, WithLogger(nil), WithDeterministic())
+		if err != nil {
+			t.Fatal(err)
+		}
+		ping, pong := s.NewChan(), s.NewChan()
+		a := s.NewG(func() {
+			for i := range 5 {
+				ping.Send(i)
+				pong.Recv()
+			}
+		}, false)
+		b := s.NewG(func() {
+			for range 5 {
+				pong.Send(ping.Recv())
+			}
+		}, false)
+		s.Enqueue(s.Ps[0], a)
+		s.Enqueue(s.Ps[1], b)
+		for range 5 {
+			s.Enqueue(s.Ps[int(seed)%2], s.NewG(func() { s.Yield() }, false))
+		}
+		s.RunDeterministic()
+
+		if a.Status() != Done || b.Status() != Done {
+			t.Fatalf("seed %d: Gs are %v and %v", seed, a.Status(), b.Status())
+		}
+		var order []byte
+		for _, e := range s.DumpTrace() {
+			if e.Kind == EventStart || e.Kind == EventResume {
+				order = append(order, byte(e.MID), byte(e.GID))
+			}
+		}
+		orders[string(order)] = true
+	}
+	if len(orders) < 2 {
+		t.Fatal("every seed produced the same interleaving")
+	}
+}
@@ -0,0 +1,75 @@
+package toysched
+
+import (
+	"container/heap"
+	"time"
+)
+
+// A G parked by SleepG, due back at when.
+type sleeper struct {
+	when time.Time
+	g    *G
+}
+
+// sleepHeap is a min-heap of sleepers keyed by wake time.
+type sleepHeap []sleeper
+
+func (h sleepHeap) Len() int           { return len(h) }
+func (h sleepHeap) Less(i, j int) bool { return h[i].when.Before(h[j].when) }
+func (h sleepHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *sleepHeap) Push(x any)        { *h = append(*h, x.(sleeper)) }
+func (h *sleepHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// SleepG suspends the calling G for d without holding its M: the G is
+// parked on the scheduler's timer heap, the M and P go on running other
+// queued Gs, and the G is requeued once d has passed. Unlike time.Sleep
+// inside a G's func, nothing sits idle meanwhile. It must be called from
+// inside a running G.
+func (s *Scheduler) SleepG(d time.Duration) {
+	g := s.mustCurrent("SleepG")
+	if d <= 0 {
+		s.Yield()
+		return
+	}
+	s.gopark(g, func() bool {
+		when := s.now().Add(d)
+		heap.Push(&s.sleepers, sleeper{when: when, g: g})
+		s.armSleepersLocked()
+		return true
+	})
+}
+
+// wakeSleepers requeues every sleeping G whose time is up and arranges
+// the next check, if any are left.
+func (s *Scheduler) wakeSleepers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if !s.sleepCheck.After(now) {
+		s.sleepCheck = time.Time{}
+	}
+	for len(s.sleepers) > 0 && !s.sleepers[0].when.After(now) {
+		sl := heap.Pop(&s.sleepers).(sleeper)
+		s.readyLocked(sl.g)
+	}
+	s.armSleepersLocked()
+}
+
+// armSleepersLocked schedules a wakeSleepers call for the earliest
+// sleeper unless one is already due by then. Caller must hold s.mu.
+func (s *Scheduler) armSleepersLocked() {
+	if len(s.sleepers) == 0 {
+		return
+	}
+	when := s.sleepers[0].when
+	if !s.sleepCheck.IsZero() && !when.Before(s.sleepCheck) {
+		return
+	}
+	s.sleepCheck = when
+	s.afterFunc(when.Sub(s.now()), s.wakeSleepers)
+}
@@ -0,0 +1,53 @@
+package toysched
+
+// Spawn returns a G for f that is queued, as by Submit, as a child of
+// parent, so a tree of Gs can be tracked as a group in the style of
+// errgroup. parent may be nil, giving a G with no parent. Cancelling a
+// parent, even one that is already running, also cancels any of its
+// children that haven't started. If the G can't be queued it is
+// Cancelled, with Err saying why.
+func (s *Scheduler) Spawn(parent *G, f func()) *G {
+	g := s.NewG(f, false)
+	if parent != nil {
+		s.mu.Lock()
+		g.parent = parent
+		parent.children = append(parent.children, g)
+		parent.pendingChildren++
+		s.mu.Unlock()
+		g.OnDone(func(*G) {
+			s.mu.Lock()
+			parent.pendingChildren--
+			s.mu.Unlock()
+		})
+	}
+	s.startAfter(g, nil)
+	return g
+}
+
+// Parent returns the G that spawned g, or nil.
+func (g *G) Parent() *G {
+	g.sched.mu.Lock()
+	defer g.sched.mu.Unlock()
+	return g.parent
+}
+
+// Children returns the Gs spawned with g as their parent, oldest first.
+// The slice is a copy.
+func (g *G) Children() []*G {
+	g.sched.mu.Lock()
+	defer g.sched.mu.Unlock()
+	return append([]*G(nil), g.children...)
+}
+
+// PendingChildren reports how many of g's children have not yet
+// finished (Done, Failed or Cancelled). Grandchildren are not counted.
+func (g *G) PendingChildren() int {
+	g.sched.mu.Lock()
+	defer g.sched.mu.Unlock()
+	return g.pendingChildren
+}
+
+// ChildrenDone reports whether every child of g has finished.
+func (g *G) ChildrenDone() bool {
+	return g.PendingChildren() == 0
+}
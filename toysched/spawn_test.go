@@ -0,0 +1,118 @@
+package toysched
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSpawnTwoLevelTree(t *testing.T) {
+	s := newDeterministic(t, 2, 2)
+	var kids []*G
+	grandkids := make(map[*G][]*G)
+	var root *G
+	root = s.Spawn(nil, func() {
+		for range 2 {
+			var kid *G
+			kid = s.Spawn(root, func() {
+				for range 2 {
+					grandkids[kid] = append(grandkids[kid], s.Spawn(kid, func() { s.Yield() }))
+				}
+			})
+			kids = append(kids, kid)
+		}
+	})
+
+	if root.Parent() != nil || len(root.Children()) != 0 || !root.ChildrenDone() {
+		t.Fatal("a fresh root has family")
+	}
+	for root.Status() != Done {
+		s.Step()
+	}
+	if got := root.Children(); !slices.Equal(got, kids) {
+		t.Fatalf("root's children = %v, want %v", gIDs(got), gIDs(kids))
+	}
+	if n := root.PendingChildren(); n != 2 || root.ChildrenDone() {
+		t.Fatalf("root has %d pending children right after spawning 2", n)
+	}
+
+	s.RunDeterministic()
+	if !root.ChildrenDone() {
+		t.Fatalf("root has %d pending children after the run", root.PendingChildren())
+	}
+	for _, kid := range kids {
+		if kid.Parent() != root {
+			t.Errorf("G%d's parent is %v, want the root", kid.ID, kid.Parent())
+		}
+		if got := kid.Children(); len(got) != 2 || !slices.Equal(got, grandkids[kid]) {
+			t.Errorf("G%d's children = %v, want %v", kid.ID, gIDs(got), gIDs(grandkids[kid]))
+		}
+		if kid.Status() != Done || !kid.ChildrenDone() {
+			t.Errorf("G%d is %v with %d pending children", kid.ID, kid.Status(), kid.PendingChildren())
+		}
+		for _, gk := range grandkids[kid] {
+			if gk.Parent() != kid || gk.Status() != Done || len(gk.Children()) != 0 {
+				t.Errorf("grandchild G%d: parent %v, %v", gk.ID, gk.Parent(), gk.Status())
+			}
+		}
+	}
+}
+
+func TestCancelParentCancelsUnstartedChildren(t *testing.T) {
+	s := newDeterministic(t, 1, 1)
+	parent := s.Spawn(nil, func() {})
+	kids := []*G{s.Spawn(parent, func() {}), s.Spawn(parent, func() {})}
+	grandkid := s.Spawn(kids[0], func() {})
+	if n := parent.PendingChildren(); n != 2 {
+		t.Fatalf("PendingChildren = %d, want 2", n)
+	}
+
+	if !parent.Cancel() {
+		t.Fatal("Cancel of a queued parent failed")
+	}
+	for _, g := range append(kids, parent, grandkid) {
+		if g.Status() != Cancelled {
+			t.Errorf("G%d is %v, want Cancelled", g.ID, g.Status())
+		}
+	}
+	if !parent.ChildrenDone() || !kids[0].ChildrenDone() {
+		t.Fatal("cancelled children still pending")
+	}
+	if s.Step() {
+		t.Fatal("work left after cancelling the whole tree")
+	}
+}
+
+func TestCancelRunningParentCancelsUnstartedChildren(t *testing.T) {
+	s := newDeterministic(t, 1, 1)
+	var kids []*G
+	var parent *G
+	parent = s.Spawn(nil, func() {
+		for range 3 {
+			kids = append(kids, s.Spawn(parent, func() {}))
+		}
+		s.Yield()
+	})
+	s.Step() // parent runs, spawns and yields
+	if len(kids) != 3 {
+		t.Fatalf("spawned %d children, want 3", len(kids))
+	}
+
+	if parent.Cancel() {
+		t.Fatal("Cancel withdrew a parent that had already started")
+	}
+	for _, g := range kids {
+		if g.Status() != Cancelled {
+			t.Errorf("G%d is %v, want Cancelled", g.ID, g.Status())
+		}
+	}
+	if !parent.ChildrenDone() {
+		t.Fatalf("PendingChildren = %d, want 0", parent.PendingChildren())
+	}
+	s.RunDeterministic()
+	if got := parent.Status(); got != Done {
+		t.Fatalf("parent is %v, want Done", got)
+	}
+	if st := s.Stats(); st.Cancelled != 3 || st.Completed != 1 {
+		t.Fatalf("Completed = %d, Cancelled = %d; want 1, 3", st.Completed, st.Cancelled)
+	}
+}
@@ -0,0 +1,66 @@
+package toysched
+
+// spinGeneration reads the count of wakeMs signals so far; an M notes it
+// before a round so a signal that lands mid-round still wakes it.
+func (s *Scheduler) spinGeneration() uint64 {
+	s.spinMu.Lock()
+	defer s.spinMu.Unlock()
+	return s.spinGen
+}
+
+// signalSpinners wakes every M waiting in spin.
+func (s *Scheduler) signalSpinners() {
+	s.spinMu.Lock()
+	s.spinGen++
+	s.spinCond.Broadcast()
+	s.spinMu.Unlock()
+}
+
+// spin accounts for one polling round of m, like the runtime's
+// nmspinning. An M that found nothing to run counts as spinning until it
+// next runs a G. Once MaxSpinning Ms are spinning, another idle M sleeps
+// on spinCond instead of polling, until wakeMs signals new work after
+// gen. Reports false if the scheduler stopped while it slept.
+func (m *M) spin(s *Scheduler, worked bool, gen uint64) bool {
+	if worked {
+		m.stopSpinning(s)
+		return true
+	}
+	if m.spinning {
+		return true
+	}
+	// Claim a slot with a CAS rather than add-then-undo, so Stats never
+	// sees the count above the cap, even briefly.
+	for {
+		n := s.spinning.Load()
+		if s.MaxSpinning > 0 && int(n) >= s.MaxSpinning {
+			break
+		}
+		if s.spinning.CompareAndSwap(n, n+1) {
+			m.spinning = true
+			return true
+		}
+	}
+
+	s.spinMu.Lock()
+	for s.spinGen == gen {
+		s.spinCond.Wait()
+	}
+	s.spinMu.Unlock()
+	select {
+	case <-m.stop:
+		return false
+	case <-s.ctx.Done():
+		return false
+	default:
+		return true
+	}
+}
+
+// stopSpinning takes m out of the spinning count, if it was in it.
+func (m *M) stopSpinning(s *Scheduler) {
+	if m.spinning {
+		m.spinning = false
+		s.spinning.Add(-1)
+	}
+}
@@ -0,0 +1,54 @@
+package toysched
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSpinningNeverExceedsCap(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxSpinning int
+		numM        int
+	}{
+		{"cap of one", 1, 6},
+		{"cap of two", 2, 6},
+		{"cap above the Ms", 10, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := NewScheduler(tt.numM, tt.numM, WithLogger(nil))
+			s.TickInterval = time.Millisecond
+			s.ParkCooldown = time.Millisecond
+			s.MaxSpinning = tt.maxSpinning
+			s.Run()
+			defer s.Stop()
+
+			// Nothing to do: every M is idle and wants to spin.
+			most := 0
+			for deadline := time.Now().Add(50 * time.Millisecond); time.Now().Before(deadline); {
+				most = max(most, s.Stats().Spinning)
+				time.Sleep(100 * time.Microsecond)
+			}
+			if want := min(tt.maxSpinning, tt.numM); most > want {
+				t.Fatalf("%d Ms spinning at once, cap %d", most, tt.maxSpinning)
+			}
+			if most == 0 {
+				t.Fatal("no M ever spun")
+			}
+
+			// Ms sleeping past the cap still wake for new work.
+			for range 3 * tt.numM {
+				if _, err := s.Submit(func() {}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := s.WaitIdle(ctx); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package toysched
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// SchedState is a JSON-friendly snapshot of the scheduler's topology,
+// copied under the lock so it stays consistent while Ms keep running.
+type SchedState struct {
+	Ps []PInfo `json:"ps"`
+	Ms []MInfo `json:"ms"`
+	// IDs of the Gs in globalQ, head first.
+	GlobalQ []int `json:"globalQ"`
+	// Every live G (queued, running, blocked or waiting), by ID.
+	Gs []GInfo `json:"gs"`
+}
+
+// PInfo is one P in a SchedState.
+type PInfo struct {
+	ID int `json:"id"`
+	// IDs of the Gs in the P's run queue, head first.
+	RunQ []int `json:"runQ"`
+}
+
+// MInfo is one M in a SchedState. P and G are -1 when the M holds none.
+type MInfo struct {
+	ID int `json:"id"`
+	P  int `json:"p"`
+	G  int `json:"g"`
+}
+
+// GInfo is one G in a SchedState.
+type GInfo struct {
+	ID     int     `json:"id"`
+	Status GStatus `json:"status"`
+}
+
+// MarshalText encodes st by name, so snapshots read well as JSON.
+func (st GStatus) MarshalText() ([]byte, error) {
+	return []byte(st.String()), nil
+}
+
+// UnmarshalText is the inverse of MarshalText.
+func (st *GStatus) UnmarshalText(b []byte) error {
+	for s := Runnable; s <= Cancelled; s++ {
+		if s.String() == string(b) {
+			*st = s
+			return nil
+		}
+	}
+	return fmt.Errorf("toysched: unknown G status %q", b)
+}
+
+// State takes a consistent snapshot of every P, M and live G.
+func (s *Scheduler) State() SchedState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	gs := make(map[int]*G)
+	st := SchedState{
+		Ps:      make([]PInfo, 0, len(s.Ps)),
+		Ms:      make([]MInfo, 0, len(s.Ms)),
+		GlobalQ: make([]int, 0, len(s.globalQ)),
+	}
+	for _, p := range s.queuesLocked() {
+		ps := PInfo{ID: p.ID, RunQ: make([]int, 0, len(p.RunQ))}
+		for _, g := range p.RunQ {
+			ps.RunQ = append(ps.RunQ, g.ID)
+			gs[g.ID] = g
+		}
+		st.Ps = append(st.Ps, ps)
+	}
+	for _, m := range s.Ms {
+		ms := MInfo{ID: m.ID, P: -1, G: -1}
+		if m.P != nil {
+			ms.P = m.P.ID
+		}
+		if m.G != nil {
+			ms.G = m.G.ID
+			gs[m.G.ID] = m.G
+		}
+		st.Ms = append(st.Ms, ms)
+	}
+	for _, g := range s.globalQ {
+		st.GlobalQ = append(st.GlobalQ, g.ID)
+		gs[g.ID] = g
+	}
+	// Started Gs that are parked somewhere else, e.g. on a SchedChan.
+	for _, g := range s.gs {
+		gs[g.ID] = g
+	}
+
+	st.Gs = make([]GInfo, 0, len(gs))
+	for _, g := range gs {
+		st.Gs = append(st.Gs, GInfo{ID: g.ID, Status: g.Status()})
+	}
+	sort.Slice(st.Gs, func(i, j int) bool { return st.Gs[i].ID < st.Gs[j].ID })
+	return st
+}
+
+// MarshalState encodes State as JSON, for external visualisers or for
+// diffing two snapshots. json.Unmarshal into a SchedState reverses it.
+func (s *Scheduler) MarshalState() ([]byte, error) {
+	return json.Marshal(s.State())
+}
+
+// QueuedGs returns the IDs of the Gs in P pID's run queue, head first,
+// or nil if there is no such P. The slice is a copy.
+func (s *Scheduler) QueuedGs(pID int) []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.queuesLocked() {
+		if p.ID == pID {
+			return gIDs(p.RunQ)
+		}
+	}
+	return nil
+}
+
+// GlobalQueued returns the IDs of the Gs in globalQ, head first. The
+// slice is a copy.
+func (s *Scheduler) GlobalQueued() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return gIDs(s.globalQ)
+}
+
+// RunningOn returns the ID of the M currently executing G gid, or
+// ok=false if the G is queued, blocked, finished or unknown.
+func (s *Scheduler) RunningOn(gid int) (mID int, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range s.Ms {
+		if g := m.G; g != nil && g.ID == gid && g.Status() == Running {
+			return m.ID, true
+		}
+	}
+	return 0, false
+}
+
+func gIDs(gs []*G) []int {
+	ids := make([]int, 0, len(gs))
+	for _, g := range gs {
+		ids = append(ids, g.ID)
+	}
+	return ids
+}
@@ -0,0 +1,168 @@
+package toysched
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestQueuedGs(t *testing.T) {
+	s := newDeterministic(t, 2, 2)
+	var ids []int
+	for range 4 {
+		g := s.NewG(func() {}, false)
+		s.Enqueue(s.Ps[0], g)
+		ids = append(ids, g.ID)
+	}
+
+	tests := []struct {
+		name string
+		pID  int
+		want []int
+	}{
+		{"busy P", 0, ids},
+		{"empty P", 1, []int{}},
+		{"no such P", 7, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.QueuedGs(tt.pID)
+			if !slices.Equal(got, tt.want) || (got == nil) != (tt.want == nil) {
+				t.Fatalf("QueuedGs(%d) = %v, want %v", tt.pID, got, tt.want)
+			}
+		})
+	}
+
+	// Returned slices are copies.
+	q := s.QueuedGs(0)
+	q[0] = -1
+	if s.QueuedGs(0)[0] != ids[0] || s.Ps[0].RunQ[0].ID != ids[0] {
+		t.Fatal("writing to QueuedGs's result changed the run queue")
+	}
+}
+
+func TestQueuedGsAfterSteal(t *testing.T) {
+	s := newDeterministic(t, 2, 2)
+	fillP(s, s.Ps[0], 8)
+	before := s.QueuedGs(0)
+
+	s.Ms[1].P.push(s.NewG(func() {}, false))
+	s.Step() // M0 runs the head of P0's queue
+	s.Step() // M1 runs its own G
+	s.Step() // M0 runs another
+	s.Step() // M1, now empty, steals 3 of the 6 left and runs the first
+
+	if got, want := s.QueuedGs(1), before[3:5]; !slices.Equal(got, want) {
+		t.Fatalf("after stealing, P1 holds %v, want %v", got, want)
+	}
+	if got, want := s.QueuedGs(0), before[5:]; !slices.Equal(got, want) {
+		t.Fatalf("after stealing, P0 holds %v, want %v", got, want)
+	}
+}
+
+func TestGlobalQueuedIsACopy(t *testing.T) {
+	s := newDeterministic(t, 1, 1)
+	fillP(s, s.Ps[0], overflowThreshold+1)
+	g := s.NewG(func() {}, false)
+	s.Enqueue(s.Ps[0], g)
+
+	q := s.GlobalQueued()
+	if !slices.Equal(q, []int{g.ID}) {
+		t.Fatalf("GlobalQueued = %v, want [%d]", q, g.ID)
+	}
+	q[0] = -1
+	if s.GlobalQueued()[0] != g.ID {
+		t.Fatal("writing to GlobalQueued's result changed globalQ")
+	}
+}
+
+func TestRunningOn(t *testing.T) {
+	s, _ := NewScheduler(2, 2, WithLogger(nil))
+	s.TickInterval = time.Millisecond
+	started, release := make(chan struct{}), make(chan struct{})
+	g := s.NewG(func() {
+		close(started)
+		<-release
+	}, false)
+	s.Enqueue(s.Ps[1], g)
+	if _, ok := s.RunningOn(g.ID); ok {
+		t.Fatal("RunningOn reports a queued G")
+	}
+	s.Run()
+	defer s.Stop()
+
+	<-started
+	wantM := -1
+	for _, e := range s.DumpTrace() {
+		if e.Kind == EventStart && e.GID == g.ID {
+			wantM = e.MID
+		}
+	}
+	for range 10 {
+		if m, ok := s.RunningOn(g.ID); !ok || m != wantM {
+			t.Fatalf("RunningOn(G%d) = M%d, %v; want M%d", g.ID, m, ok, wantM)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	g.Wait()
+	if m, ok := s.RunningOn(g.ID); ok {
+		t.Fatalf("RunningOn reports finished G%d on M%d", g.ID, m)
+	}
+	if _, ok := s.RunningOn(g.ID + 100); ok {
+		t.Fatal("RunningOn reports an unknown G")
+	}
+}
+
+func TestRunningOnBlockedG(t *testing.T) {
+	s := newDeterministic(t, 1, 2)
+	g := s.NewGBlocking(func() {}, 0)
+	s.Enqueue(s.Ps[0], g)
+	s.Step() // g blocks, its M holding it
+	if g.Status() != Blocked {
+		t.Fatalf("G is %v, want Blocked", g.Status())
+	}
+	if m, ok := s.RunningOn(g.ID); ok {
+		t.Fatalf("RunningOn reports blocked G%d on M%d", g.ID, m)
+	}
+	g.Unblock()
+	s.RunDeterministic()
+}
+
+func TestMarshalStateRoundTrip(t *testing.T) {
+	s := newDeterministic(t, 2, 2)
+	s.Enqueue(s.Ps[0], s.NewG(func() {}, true))
+	s.Step() // M0 runs the G, which blocks holding it
+	fillP(s, s.Ps[1], overflowThreshold+1)
+	s.Enqueue(s.Ps[1], s.NewG(func() {}, false)) // P1 full: onto globalQ
+
+	want := s.State()
+	if len(want.GlobalQ) == 0 || want.Ms[0].G < 0 {
+		t.Fatalf("setup: want a G on globalQ and one on M0, got %+v", want)
+	}
+	b, err := s.MarshalState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got SchedState
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip:\n got %+v\nwant %+v\njson %s", got, want, b)
+	}
+
+	for st := Runnable; st <= Cancelled; st++ {
+		text, _ := st.MarshalText()
+		var back GStatus
+		if err := back.UnmarshalText(text); err != nil || back != st {
+			t.Errorf("UnmarshalText(%q) = %v, %v; want %v", text, back, err, st)
+		}
+	}
+	var bad GStatus
+	if err := bad.UnmarshalText([]byte("Sleeping")); err == nil {
+		t.Fatal("UnmarshalText of an unknown status succeeded")
+	}
+}
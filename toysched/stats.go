@@ -0,0 +1,211 @@
+package toysched
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// SchedStats is a point-in-time snapshot of the scheduler's state.
+type SchedStats struct {
+	// Gs created via NewG (and friends) so far.
+	Created int
+	// Gs that finished normally / panicked / were cancelled unrun.
+	Completed int
+	Failed    int
+	Cancelled int
+	// Gs that finished normally, by G.Tenant.
+	TenantCompleted map[int]int
+
+	// Current RunQ depth of each P, indexed like Scheduler.Ps.
+	QueueDepths []int
+	// Current length of globalQ.
+	GlobalQueue int
+	// Ms holding neither a P nor a G.
+	ParkedMs int
+	// Each M's current state, and how many scheduling rounds it has
+	// spent in each state so far; indexed like Scheduler.Ms.
+	MStates     []MState
+	MStateTicks []MStateTicks
+	// Percentage of wall-clock time since Run (or the first Step) each
+	// M spent running Gs, as opposed to parked, spinning or waiting on
+	// a syscall; indexed like Scheduler.Ms.
+	MUtilization []float64
+	// How long each M currently sleeps between polling rounds (see
+	// AdaptiveTick), or 0 if EventDriven; indexed like Scheduler.Ms.
+	MTickIntervals []time.Duration
+	// Idle Ms currently polling for work; see MaxSpinning.
+	Spinning int
+
+	// Cumulative steal operations (from globalQ or a peer P).
+	Steals int
+	// Cumulative Ps handed back to availPs (park or block).
+	Handoffs int
+	// Cumulative Ps taken from availPs by idle Ms.
+	Grabs int
+	// Cumulative attempts by Ms with a just-unblocked G to take a P
+	// from availPs, successful or not; see ResumeJitter.
+	ResumeGrabs int
+	// Cumulative grab attempts skipped because the M was still in its
+	// ParkCooldown.
+	CooldownSkips int
+	// Cumulative times a G was preempted for exceeding its TimeBudget.
+	Preemptions int
+	// Cumulative Gs run ahead of their turn for having waited past
+	// StarvationThreshold.
+	Promotions int
+	// StopTheWorld pauses completed so far, their total and longest.
+	STWPauses int
+	STWTotal  time.Duration
+	STWMax    time.Duration
+}
+
+// Stats returns a consistent snapshot of the scheduler's counters.
+func (s *Scheduler) Stats() SchedStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := SchedStats{
+		Created:       s.created,
+		Completed:     s.completed,
+		Failed:        s.failed,
+		Cancelled:     s.cancelled,
+		QueueDepths:   make([]int, len(s.Ps)),
+		GlobalQueue:   len(s.globalQ),
+		Steals:        s.steals,
+		Handoffs:      s.handoffs,
+		Grabs:         s.grabs,
+		ResumeGrabs:   s.resumeGrabs,
+		CooldownSkips: int(s.cooldownSkips.Load()),
+		Preemptions:   s.preemptions,
+		Promotions:    s.promotions,
+		STWPauses:     s.stwCount,
+		STWTotal:      s.stwTotal,
+		STWMax:        s.stwMax,
+		Spinning:      int(s.spinning.Load()),
+	}
+	for i, p := range s.Ps {
+		st.QueueDepths[i] = p.NumG
+	}
+	var elapsed time.Duration
+	if !s.startedAt.IsZero() {
+		elapsed = time.Since(s.startedAt)
+	}
+	st.TenantCompleted = make(map[int]int, len(s.tenantDone))
+	for t, n := range s.tenantDone {
+		st.TenantCompleted[t] = n
+	}
+	for _, m := range s.Ms {
+		if m.P == nil && m.G == nil {
+			st.ParkedMs++
+		}
+		st.MStates = append(st.MStates, m.State())
+		st.MStateTicks = append(st.MStateTicks, m.stateTicks())
+		st.MUtilization = append(st.MUtilization, m.utilization(elapsed))
+		st.MTickIntervals = append(st.MTickIntervals, m.tickInterval(s))
+	}
+	return st
+}
+
+// How many wait latencies the scheduler keeps for LatencyReport. Up to
+// this many finished Gs the figures are exact; beyond it they come from
+// a uniform sample of this size, so memory stays bounded however many
+// Gs run.
+const latencySamples = 4096
+
+// latencyReservoir keeps a uniform random sample of at most
+// latencySamples durations (reservoir sampling, Algorithm R), plus how
+// many were offered. The sampling uses its own fixed seed, so a
+// deterministic run reports the same figures every time.
+type latencyReservoir struct {
+	samples []time.Duration
+	seen    int
+	rng     *rand.Rand
+}
+
+func (r *latencyReservoir) add(d time.Duration) {
+	r.seen++
+	if len(r.samples) < latencySamples {
+		r.samples = append(r.samples, d)
+		return
+	}
+	if r.rng == nil {
+		r.rng = rand.New(rand.NewSource(1))
+	}
+	if i := r.rng.Intn(r.seen); i < latencySamples {
+		r.samples[i] = d
+	}
+}
+
+// LatencyReport summarises how long finished Gs waited in a queue
+// before they first ran (see G.WaitLatency).
+type LatencyReport struct {
+	// Gs that finished normally so far; the quantiles are estimated
+	// from a sample of up to 4096 of them.
+	Count int
+	// Wait latency quantiles.
+	P50, P95, P99 time.Duration
+}
+
+func (r LatencyReport) String() string {
+	return fmt.Sprintf("Wait latency quantiles (from %d Gs): 50%%=%v, 95%%=%v, 99%%=%v",
+		r.Count, r.P50, r.P95, r.P99)
+}
+
+// LatencyReport aggregates the wait latency of the Gs that have
+// finished normally so far (exactly, or from a sample; see Count).
+func (s *Scheduler) LatencyReport() LatencyReport {
+	lats, count := s.sortedLatencies()
+	n := len(lats)
+	if n == 0 {
+		return LatencyReport{}
+	}
+	return LatencyReport{
+		Count: count,
+		P50:   lats[n/2],
+		P95:   lats[int(float64(n)*0.95)],
+		P99:   lats[int(float64(n)*0.99)],
+	}
+}
+
+// LatencyWithin reports whether the p quantile (0.99 for p99) of the
+// wait latency of Gs finished so far is below threshold, for SLO-style
+// checks such as "p99 must stay under 5ms with this placement". It is
+// true when no G has finished yet. See LatencyViolations for how many
+// Gs missed the threshold.
+func (s *Scheduler) LatencyWithin(p float64, threshold time.Duration) bool {
+	lats, _ := s.sortedLatencies()
+	if len(lats) == 0 {
+		return true
+	}
+	i := min(int(float64(len(lats))*p), len(lats)-1)
+	return lats[max(i, 0)] < threshold
+}
+
+// LatencyViolations counts the finished Gs whose wait latency was
+// threshold or more. Past 4096 finished Gs it is scaled up from the
+// sample LatencyReport uses, so it is an estimate.
+func (s *Scheduler) LatencyViolations(threshold time.Duration) int {
+	lats, count := s.sortedLatencies()
+	if len(lats) == 0 {
+		return 0
+	}
+	n := len(lats) - sort.Search(len(lats), func(i int) bool { return lats[i] >= threshold })
+	if count == len(lats) {
+		return n
+	}
+	return int(float64(n) * float64(count) / float64(len(lats)))
+}
+
+// sortedLatencies returns a sorted copy of the sampled wait latencies
+// and how many Gs they were sampled from.
+func (s *Scheduler) sortedLatencies() ([]time.Duration, int) {
+	s.mu.Lock()
+	lats := make([]time.Duration, len(s.waitLatencies.samples))
+	copy(lats, s.waitLatencies.samples)
+	count := s.waitLatencies.seen
+	s.mu.Unlock()
+	sort.Slice(lats, func(i, j int) bool { return lats[i] < lats[j] })
+	return lats, count
+}
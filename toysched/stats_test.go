@@ -0,0 +1,101 @@
+package toysched
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyReservoirIsBounded(t *testing.T) {
+	tests := []struct {
+		name        string
+		n           int
+		wantSamples int
+	}{
+		{"under the cap", 100, 100},
+		{"at the cap", latencySamples, latencySamples},
+		{"far over the cap", 50 * latencySamples, latencySamples},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r latencyReservoir
+			for i := range tt.n {
+				r.add(time.Duration(i%100) * time.Millisecond)
+			}
+			if len(r.samples) != tt.wantSamples || r.seen != tt.n {
+				t.Fatalf("kept %d of %d, want %d", len(r.samples), r.seen, tt.wantSamples)
+			}
+		})
+	}
+}
+
+func TestLatencyReportFromSample(t *testing.T) {
+	s, _ := NewScheduler(1, 1)
+	const n = 10 * latencySamples
+	// Uniform 0-99ms, so p50 is about 50ms and 10% are 90ms or more.
+	for i := range n {
+		s.waitLatencies.add(time.Duration(i%100) * time.Millisecond)
+	}
+	r := s.LatencyReport()
+	if r.Count != n {
+		t.Fatalf("Count = %d, want %d", r.Count, n)
+	}
+	if r.P50 < 45*time.Millisecond || r.P50 > 55*time.Millisecond {
+		t.Errorf("P50 = %v, want about 50ms", r.P50)
+	}
+	if r.P99 < 97*time.Millisecond {
+		t.Errorf("P99 = %v, want about 99ms", r.P99)
+	}
+	if v := s.LatencyViolations(90 * time.Millisecond); v < n/12 || v > n/8 {
+		t.Errorf("LatencyViolations = %d, want about %d", v, n/10)
+	}
+	if !s.LatencyWithin(0.5, 60*time.Millisecond) || s.LatencyWithin(0.99, 90*time.Millisecond) {
+		t.Error("LatencyWithin disagrees with the quantiles")
+	}
+}
+
+func TestLatencyReportExactUnderCap(t *testing.T) {
+	s := newDeterministic(t, 1, 1)
+	for range 10 {
+		s.Enqueue(s.Ps[0], s.NewG(func() {}, false))
+	}
+	s.RunDeterministic()
+	r := s.LatencyReport()
+	if r.Count != 10 {
+		t.Fatalf("Count = %d, want 10", r.Count)
+	}
+	// One G per tick, from 1 to 10 ticks.
+	if r.P50 != 6*s.TickInterval || r.P99 != 10*s.TickInterval {
+		t.Fatalf("report = %+v", r)
+	}
+	if got := s.LatencyViolations(6 * s.TickInterval); got != 5 {
+		t.Fatalf("LatencyViolations = %d, want 5", got)
+	}
+}
+
+func TestLatencyWithinSLO(t *testing.T) {
+	tests := []struct {
+		name string
+		// P the i'th G is pinned to, so stealing can't even things out.
+		pinTo func(i int) int
+		pass  bool
+	}{
+		{"overloaded P0", func(int) int { return 0 }, false},
+		{"rebalanced", func(i int) int { return i % 4 }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, 4, 4)
+			for i := range 40 {
+				s.Enqueue(s.Ps[0], s.NewGPinned(func() {}, tt.pinTo(i)))
+			}
+			s.RunDeterministic()
+			slo := 12 * s.TickInterval
+			if got := s.LatencyWithin(0.99, slo); got != tt.pass {
+				t.Fatalf("LatencyWithin(0.99, %v) = %v, want %v; %v", slo, got, tt.pass, s.LatencyReport())
+			}
+			if v := s.LatencyViolations(slo); (v == 0) != tt.pass {
+				t.Fatalf("LatencyViolations(%v) = %d", slo, v)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package toysched
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// StealPolicy is how much an idle M takes from a peer P's run queue.
+type StealPolicy int
+
+const (
+	// StealHalf takes the older half of the victim's queue, rounded up,
+	// as the real runtime does. It is the default.
+	StealHalf StealPolicy = iota
+	// StealOne takes only the G at the head of the victim's queue, so
+	// queues even out more slowly and Ms steal more often.
+	StealOne
+)
+
+func (sp StealPolicy) String() string {
+	switch sp {
+	case StealHalf:
+		return "steal-half"
+	case StealOne:
+		return "steal-one"
+	}
+	return fmt.Sprintf("StealPolicy(%d)", int(sp))
+}
+
+// VictimSelector picks which peer P an idle M steals from. peers holds
+// every other P with Gs it can give up, in P order, and is never empty.
+// Pick is called with the scheduler's lock held, so implementations need
+// no locking of their own but must not call back into the Scheduler.
+// Returning nil skips the steal.
+type VictimSelector interface {
+	Pick(thief *P, peers []*P) *P
+}
+
+// MostLoaded steals from the peer with the longest queue, the first such
+// P on a tie. Every idle M converges on the same victim, so under skewed
+// load they queue up behind one another on it.
+type MostLoaded struct{}
+
+func (MostLoaded) Pick(_ *P, peers []*P) *P {
+	victim, most := peers[0], unpinnedLen(peers[0])
+	for _, peer := range peers[1:] {
+		if n := unpinnedLen(peer); n > most {
+			victim, most = peer, n
+		}
+	}
+	return victim
+}
+
+// RandomVictim steals from a peer chosen uniformly at random, as the
+// real runtime does, which spreads thieves across victims. Rand, if set,
+// makes the choice reproducible; nil uses the math/rand global source.
+type RandomVictim struct {
+	Rand *rand.Rand
+}
+
+func (r RandomVictim) Pick(_ *P, peers []*P) *P {
+	if r.Rand != nil {
+		return peers[r.Rand.Intn(len(peers))]
+	}
+	return peers[rand.Intn(len(peers))]
+}
+
+// RoundRobinVictim cycles through the Ps, stealing from the first peer
+// after the last victim by P ID. Use it by pointer, as it keeps state.
+type RoundRobinVictim struct {
+	last int
+}
+
+func (rr *RoundRobinVictim) Pick(_ *P, peers []*P) *P {
+	victim := peers[0]
+	for _, peer := range peers {
+		if peer.ID > rr.last {
+			victim = peer
+			break
+		}
+	}
+	rr.last = victim.ID
+	return victim
+}
@@ -0,0 +1,201 @@
+package toysched
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// startsByP counts, from the trace ring, how many Gs first started on
+// each P.
+func startsByP(s *Scheduler) map[int]int {
+	n := make(map[int]int)
+	for _, e := range s.DumpTrace() {
+		if e.Kind == EventStart {
+			n[e.PID]++
+		}
+	}
+	return n
+}
+
+func TestStealFromPeerTakesOlderHalf(t *testing.T) {
+	tests := []struct {
+		name       string
+		queued     []int
+		wantVictim int
+		wantN      int
+	}{
+		{"nothing to steal", []int{0, 0, 0}, -1, 0},
+		{"one G", []int{0, 1, 0}, 1, 1},
+		{"odd queue rounds up", []int{0, 7, 0}, 1, 4},
+		{"busiest peer", []int{0, 2, 6}, 2, 3},
+		{"tie goes to first", []int{0, 4, 4}, 1, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, len(tt.queued), 0)
+			for i, n := range tt.queued {
+				for range n {
+					s.Ps[i].push(s.NewG(func() {}, false))
+				}
+			}
+			var want []*G
+			if tt.wantVictim >= 0 {
+				want = append(want, s.Ps[tt.wantVictim].RunQ[:tt.wantN]...)
+			}
+
+			victim, n := s.stealFromPeer(s.Ps[0])
+			if n != tt.wantN {
+				t.Fatalf("stole %d Gs, want %d", n, tt.wantN)
+			}
+			if n == 0 {
+				return
+			}
+			if victim != s.Ps[tt.wantVictim] {
+				t.Fatalf("stole from P%d, want P%d", victim.ID, tt.wantVictim)
+			}
+			thief := s.Ps[0]
+			for i, g := range want {
+				if thief.RunQ[i] != g {
+					t.Fatalf("thief's queue[%d] = G%d, want G%d", i, thief.RunQ[i].ID, g.ID)
+				}
+			}
+			if thief.NumG != n || victim.NumG != tt.queued[tt.wantVictim]-n {
+				t.Fatalf("NumG: thief %d, victim %d", thief.NumG, victim.NumG)
+			}
+		})
+	}
+}
+
+func TestIdleMStealsFromBusyPeer(t *testing.T) {
+	s := newDeterministic(t, 2, 2)
+	for range 10 {
+		s.Ps[0].push(s.NewG(func() {}, false))
+	}
+	s.RunDeterministic()
+
+	if st := s.Stats(); st.Completed != 10 || st.Steals == 0 {
+		t.Fatalf("Completed = %d, Steals = %d", st.Completed, st.Steals)
+	}
+	n := startsByP(s)
+	if n[0] < 3 || n[1] < 3 {
+		t.Fatalf("Gs started per P = %v, want a roughly even split", n)
+	}
+}
+
+func TestStealPolicy(t *testing.T) {
+	tests := []struct {
+		policy StealPolicy
+		want   int
+		name   string
+	}{
+		{StealHalf, 4, "steal-half"},
+		{StealOne, 1, "steal-one"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, 2, 2)
+			s.StealPolicy = tt.policy
+			fillP(s, s.Ps[0], 8)
+			if _, n := s.stealFromPeer(s.Ps[1]); n != tt.want {
+				t.Fatalf("stole %d Gs in one go, want %d", n, tt.want)
+			}
+			if s.Ps[0].NumG != 8-tt.want || s.Ps[1].NumG != tt.want {
+				t.Fatalf("P0 holds %d, P1 %d", s.Ps[0].NumG, s.Ps[1].NumG)
+			}
+			if got := tt.policy.String(); got != tt.name {
+				t.Fatalf("String() = %q, want %q", got, tt.name)
+			}
+		})
+	}
+}
+
+func TestStealOneStealsMoreOften(t *testing.T) {
+	steals := func(policy StealPolicy) int {
+		s := newDeterministic(t, 2, 2)
+		s.StealPolicy = policy
+		fillP(s, s.Ps[0], 16)
+		s.RunDeterministic()
+		return s.Stats().Steals
+	}
+	half, one := steals(StealHalf), steals(StealOne)
+	if one <= half {
+		t.Fatalf("StealOne made %d steals, StealHalf %d; want more with StealOne", one, half)
+	}
+}
+
+func TestVictimSelectorPick(t *testing.T) {
+	peers := func(lens ...int) []*P {
+		var ps []*P
+		for i, n := range lens {
+			p := &P{ID: i + 1}
+			for range n {
+				p.push(&G{PinnedP: -1})
+			}
+			ps = append(ps, p)
+		}
+		return ps
+	}
+	tests := []struct {
+		name  string
+		sel   VictimSelector
+		peers []*P
+		want  []int // IDs of successive picks
+	}{
+		{"most loaded", MostLoaded{}, peers(2, 7, 3), []int{2, 2}},
+		{"most loaded tie", MostLoaded{}, peers(4, 4, 1), []int{1, 1}},
+		{"round robin", &RoundRobinVictim{}, peers(1, 1, 1), []int{1, 2, 3, 1, 2}},
+		{"round robin single", &RoundRobinVictim{}, peers(5), []int{1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, want := range tt.want {
+				if got := tt.sel.Pick(nil, tt.peers); got.ID != want {
+					t.Fatalf("pick %d = P%d, want P%d", i, got.ID, want)
+				}
+			}
+		})
+	}
+}
+
+// TestVictimSpreadUnderSkew has four idle Ps steal in turn from a
+// skewed pair of victims: P4 with 30 Gs and P5 with 2.
+func TestVictimSpreadUnderSkew(t *testing.T) {
+	const trials = 200
+	tests := []struct {
+		name string
+		sel  func(seed int64) VictimSelector
+		// Share of all steals expected to hit the light P5.
+		minLight, maxLight float64
+	}{
+		// Every thief converges on the busiest P.
+		{"most loaded", func(int64) VictimSelector { return MostLoaded{} }, 0, 0},
+		{"random", func(seed int64) VictimSelector {
+			return RandomVictim{Rand: rand.New(rand.NewSource(seed))}
+		}, 0.2, 0.6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			victims := make(map[int]int)
+			for seed := range int64(trials) {
+				s := newDeterministic(t, 6, 0)
+				s.VictimSelector = tt.sel(seed)
+				fillP(s, s.Ps[4], 30)
+				fillP(s, s.Ps[5], 2)
+				for _, thief := range s.Ps[:4] {
+					if victim, _ := s.stealFromPeer(thief); victim != nil {
+						victims[victim.ID]++
+					}
+					// The thief runs what it stole, so it is never a victim.
+					thief.RunQ, thief.NumG = nil, 0
+				}
+			}
+			total := victims[4] + victims[5]
+			if total != 4*trials {
+				t.Fatalf("steals by victim = %v, want %d in all", victims, 4*trials)
+			}
+			if share := float64(victims[5]) / float64(total); share < tt.minLight || share > tt.maxLight {
+				t.Fatalf("%.2f of steals hit P5 (%v), want %.2f-%.2f", share, victims, tt.minLight, tt.maxLight)
+			}
+		})
+	}
+}
@@ -131,6 +131,14 @@ func (m *M) Schedule() {
 	fmt.Printf("M%d on P%d: Finished G%d\n", m.ID, m.P.ID, g.ID)
 }
 
+// RunUntilEmpty calls Schedule until the M's P has no Gs left, so a
+// single call drains the queue however many Gs were enqueued.
+func (m *M) RunUntilEmpty() {
+	for m.P != nil && m.P.NumG > 0 {
+		m.Schedule()
+	}
+}
+
 func main() {
 
 	sched := &Scheduler{}
@@ -176,17 +184,16 @@ func main() {
 	sched.Enqueue(p0, g1)
 	sched.Enqueue(p0, g2)
 
-	// Run the scheduler: Just call Schedule on M0 once.
+	// Run the scheduler: keep calling Schedule on M0 until P0 is drained.
 	fmt.Println("=== Starting Toy Schedule ===")
 
-	for i := 0; i < 2; i++ {
-		m0.Schedule()
-	}
+	m0.RunUntilEmpty()
 
 	fmt.Println("=== Schedule Complete ===")
 }
 
 /*
 Question:  Why did the logs not contain all the expected results?
-- 
+- The loop used to call Schedule a hand-picked 2 times, but 3 Gs were
+  enqueued, so G2 never ran. Looping while P0 still has Gs runs them all.
 */
\ No newline at end of file
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// captureStdout returns what f prints.
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	out := make(chan string)
+	go func() {
+		var b bytes.Buffer
+		io.Copy(&b, r)
+		out <- b.String()
+	}()
+	f()
+	w.Close()
+	return <-out
+}
+
+func TestRunUntilEmptyFinishesEveryG(t *testing.T) {
+	for _, n := range []int{0, 1, 3, 10} {
+		sched := &Scheduler{}
+		p0 := sched.AddP(0)
+		m0 := sched.AddM(0, 0)
+		var gs []*G
+		for range n {
+			g := sched.NewG(func() {})
+			sched.Enqueue(p0, g)
+			gs = append(gs, g)
+		}
+
+		out := captureStdout(t, m0.RunUntilEmpty)
+		if got := strings.Count(out, "Finished G"); got != n {
+			t.Errorf("%d Gs: %d \"Finished G\" lines", n, got)
+		}
+		for _, g := range gs {
+			if g.Status != "done" {
+				t.Errorf("%d Gs: G%d is %q", n, g.ID, g.Status)
+			}
+		}
+	}
+}
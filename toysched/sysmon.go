@@ -0,0 +1,65 @@
+package toysched
+
+import "time"
+
+// startSysmon is the toy version of the runtime's sysmon: a background
+// goroutine, launched by Run when SysmonThreshold is set, that retakes
+// Ps from Ms whose G has been blocked too long so other work proceeds.
+func (s *Scheduler) startSysmon() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(max(s.SysmonThreshold/2, time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		s.retake()
+	}
+}
+
+// handoff records that M mID gave up P pID.
+type handoff struct{ mID, pID int }
+
+// retake runs retakeLocked, then reports each retaken P to OnHandoff
+// once the lock is released.
+func (s *Scheduler) retake() {
+	s.mu.Lock()
+	taken := s.retakeLocked()
+	onHandoff := s.onHandoff
+	s.mu.Unlock()
+	if onHandoff != nil {
+		for _, h := range taken {
+			onHandoff(h.mID, h.pID)
+		}
+	}
+}
+
+// retakeLocked hands off the P of every M whose G has been blocked for
+// at least SysmonThreshold. The M keeps its G and, once the G wakes,
+// acquires a fresh P in exitSyscall like any other M. It returns the
+// handoffs made. Caller must hold s.mu.
+func (s *Scheduler) retakeLocked() []handoff {
+	var taken []handoff
+	now := s.now()
+	for _, m := range s.Ms {
+		g, p := m.G, m.P
+		if g == nil || p == nil || p == s.sysP || s.blockedGs[g.ID] != g {
+			continue
+		}
+		if now.Sub(g.blockedAt) < s.SysmonThreshold {
+			continue
+		}
+		s.emit(SchedEvent{Kind: EventRetake, MID: m.ID, PID: p.ID, GID: g.ID})
+		s.releasePLocked(p)
+		s.handoffs++
+		m.lastP = p
+		m.P = nil
+		taken = append(taken, handoff{m.ID, p.ID})
+		s.wakeMs()
+	}
+	return taken
+}
@@ -0,0 +1,178 @@
+package toysched
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSysmonRetakeRacesWithResume(t *testing.T) {
+	for _, jitter := range []time.Duration{0, 2 * time.Millisecond} {
+		s, _ := NewScheduler(2, 6)
+		s.SetConsole(nil)
+		s.TickInterval = time.Millisecond
+		s.ParkCooldown = 0
+		s.SysmonThreshold = time.Millisecond
+		s.ResumeJitter = jitter
+		var gs []*G
+		for i := range 12 {
+			// Unblock around the retake threshold, either side of it.
+			g := s.NewGBlocking(func() {}, time.Duration(i%4+1)*time.Millisecond/2)
+			s.Enqueue(s.Ps[i%2], g)
+			gs = append(gs, g)
+		}
+		s.Run()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := s.WaitIdle(ctx)
+		cancel()
+		s.Stop()
+		if err != nil {
+			t.Fatalf("jitter %v: %v", jitter, err)
+		}
+		for _, g := range gs {
+			if g.Status() != Done {
+				t.Fatalf("jitter %v: G%d is %v", jitter, g.ID, g.Status())
+			}
+		}
+		if err := s.Check(); err != nil {
+			t.Fatalf("jitter %v: %v", jitter, err)
+		}
+	}
+}
+
+func TestSysmonRetakesLongSyscall(t *testing.T) {
+	s := newDeterministic(t, 1, 2)
+	s.SysmonThreshold = 2 * s.TickInterval
+	s.Enqueue(s.Ps[0], s.NewGBlocking(func() {}, 10*s.TickInterval))
+	s.Enqueue(s.Ps[0], s.NewG(func() {}, false))
+	s.RunDeterministic()
+	if st := s.Stats(); st.Completed != 2 || st.Handoffs == 0 {
+		t.Fatalf("Completed = %d, Handoffs = %d; want 2 and a retake", st.Completed, st.Handoffs)
+	}
+}
+
+func TestSysmonRetakeThreshold(t *testing.T) {
+	tests := []struct {
+		name       string
+		threshold  int // ticks
+		block      int // ticks
+		wantRetake bool
+	}{
+		{"long syscall", 2, 10, true},
+		{"short syscall", 8, 3, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, 1, 2)
+			s.SysmonThreshold = time.Duration(tt.threshold) * s.TickInterval
+			blocked := s.NewGBlocking(func() {}, time.Duration(tt.block)*s.TickInterval)
+			other := s.NewG(func() {}, false)
+			s.Enqueue(s.Ps[0], blocked)
+			s.Enqueue(s.Ps[0], other)
+			s.RunDeterministic()
+
+			if blocked.Status() != Done || other.Status() != Done {
+				t.Fatalf("blocked G %v, other G %v", blocked.Status(), other.Status())
+			}
+			var retook bool
+			var otherP int
+			for _, e := range s.DumpTrace() {
+				switch {
+				case e.Kind == EventRetake:
+					retook = true
+				case e.Kind == EventStart && e.GID == other.ID:
+					otherP = e.PID
+				}
+			}
+			if retook != tt.wantRetake {
+				t.Fatalf("retake: %v, want %v", retook, tt.wantRetake)
+			}
+			// With one P, the other G can only run on it, and only
+			// before the syscall ends if sysmon retook it.
+			if otherP != 0 {
+				t.Fatalf("other G started on P%d", otherP)
+			}
+			if got := other.FinishedAt.Before(blocked.FinishedAt); got != tt.wantRetake {
+				t.Fatalf("other G finished during the syscall: %v, want %v", got, tt.wantRetake)
+			}
+		})
+	}
+}
+
+func TestResumeJitterSpreadsGrabs(t *testing.T) {
+	const n = 20
+	tests := []struct {
+		name               string
+		jitter             int // in ticks
+		minTicks, maxTicks int // ticks with resume grabs in them
+		maxBurst           int // most resume grabs in one tick
+	}{
+		// Every M goes for a P in the same tick.
+		{"no jitter", 0, 1, 1, n},
+		{"ten ticks", 10, 3, 11, n / 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, 2, n+2)
+			s.ParkCooldown = 0
+			s.ResumeJitter = time.Duration(tt.jitter) * s.TickInterval
+			var gs []*G
+			for i := range n {
+				g := s.NewGBlocking(func() {}, 0)
+				s.Enqueue(s.Ps[i%2], g)
+				gs = append(gs, g)
+			}
+			blocked := func() bool {
+				for _, g := range gs {
+					if g.Status() != Blocked {
+						return false
+					}
+				}
+				return true
+			}
+			for range 1000 {
+				if blocked() {
+					break
+				}
+				s.Step()
+			}
+			if !blocked() {
+				t.Fatal("not every G blocked, each on its own M")
+			}
+
+			// Wake the whole herd at once, then count grabs per tick.
+			for _, g := range gs {
+				g.Unblock()
+			}
+			perTick := make(map[int]int)
+			before := s.Stats().ResumeGrabs
+			for tick := 0; s.Step(); tick++ {
+				for range len(s.Ms) - 1 {
+					s.Step()
+				}
+				now := s.Stats().ResumeGrabs
+				if now > before {
+					perTick[tick] = now - before
+				}
+				before = now
+			}
+			burst, total := 0, 0
+			for _, g := range perTick {
+				burst = max(burst, g)
+				total += g
+			}
+			if total != n {
+				t.Fatalf("%d resume grabs, want %d", total, n)
+			}
+			if len(perTick) < tt.minTicks || len(perTick) > tt.maxTicks || burst > tt.maxBurst {
+				t.Fatalf("resume grabs by tick = %v, want over %d-%d ticks, at most %d in one",
+					perTick, tt.minTicks, tt.maxTicks, tt.maxBurst)
+			}
+			for _, g := range gs {
+				if g.Status() != Done {
+					t.Fatalf("G%d is %v", g.ID, g.Status())
+				}
+			}
+		})
+	}
+}
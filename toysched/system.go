@@ -0,0 +1,45 @@
+package toysched
+
+// WithSystemP reserves a P, with an M of its own, for Gs queued by
+// SubmitSystem, the way the runtime keeps some maintenance work off the
+// Ps that run user code. The system P comes on top of the numP user Ps
+// and is not in Ps: placement, stealing and SetNumP never see it. Its M
+// never hands it off, not even while a system G blocks, and never takes
+// user work, so a flood of user Gs can't starve maintenance Gs and
+// maintenance Gs can't crowd out user Gs.
+func WithSystemP() Option {
+	return func(s *Scheduler) { s.wantSysP = true }
+}
+
+// SubmitSystem queues f as a G on the system P. It fails with
+// ErrNoSystemP unless the scheduler was made with WithSystemP, with
+// ErrTooManyGs at MaxGs, and with ErrShutdown once Shutdown has begun.
+func (s *Scheduler) SubmitSystem(f func()) (*G, error) {
+	if s.sysP == nil {
+		return nil, ErrNoSystemP
+	}
+	return s.submit(func() *G { return s.NewG(f, false) }, s.placeSystemLocked)
+}
+
+// placeSystemLocked queues g on the system P, bypassing the placement
+// policy and any cap. Caller must hold s.mu.
+func (s *Scheduler) placeSystemLocked(g *G) error {
+	if s.shutdown {
+		return ErrShutdown
+	}
+	g.readyAt = s.now()
+	g.lastP = s.sysP
+	g.setStatus(Runnable)
+	s.sysP.push(g)
+	s.wakeMs()
+	return nil
+}
+
+// queuesLocked returns every P that can hold queued Gs: Ps plus the
+// system P, if any. Caller must hold s.mu.
+func (s *Scheduler) queuesLocked() []*P {
+	if s.sysP == nil {
+		return s.Ps
+	}
+	return append(s.Ps[:len(s.Ps):len(s.Ps)], s.sysP)
+}
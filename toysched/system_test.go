@@ -0,0 +1,112 @@
+package toysched
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSubmitFromSystemGStaysOffSystemP(t *testing.T) {
+	s, err := NewScheduler(2, 2, WithSystemP())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetConsole(nil)
+
+	var user *G
+	sys, err := s.SubmitSystem(func() {
+		user, _ = s.Submit(func() {})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Run only the system M, so the user G stays wherever it was put.
+	sysM := s.Ms[len(s.Ms)-1]
+	for sys.Status() != Done {
+		sysM.scheduleOnce(s)
+	}
+	if user == nil {
+		t.Fatal("Submit from the system G failed")
+	}
+	if q := s.QueuedGs(s.sysP.ID); len(q) != 0 {
+		t.Fatalf("system P queue = %v, want empty", q)
+	}
+	if err := s.Check(); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Run()
+	if err := s.WaitIdle(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	s.Stop()
+	if user.Status() != Done {
+		t.Fatalf("user G is %v, want done", user.Status())
+	}
+}
+
+func TestPlacementNeverPicksSystemP(t *testing.T) {
+	s, _ := NewScheduler(1, 1, WithSystemP(), WithPlacement(placeOn{}))
+	s.SetConsole(nil)
+	if err := s.Enqueue(s.sysP, s.NewG(func() {}, false)); err != nil {
+		t.Fatal(err)
+	}
+	if q := s.QueuedGs(s.sysP.ID); len(q) != 0 {
+		t.Fatalf("system P queue = %v, want empty", q)
+	}
+}
+
+// placeOn returns the G's suggested P, whatever it is.
+type placeOn struct{}
+
+func (placeOn) Place(s *Scheduler, g *G) *P { return g.lastP }
+
+func TestSubmitSystemWithoutSystemP(t *testing.T) {
+	s, _ := NewScheduler(1, 1)
+	if _, err := s.SubmitSystem(func() {}); !errors.Is(err, ErrNoSystemP) {
+		t.Fatalf("err = %v, want ErrNoSystemP", err)
+	}
+}
+
+func TestSystemPRunsWhileUserPsSaturated(t *testing.T) {
+	s := newDeterministic(t, 2, 2, WithSystemP())
+	for i := range 2 * (overflowThreshold + 1) {
+		s.Enqueue(s.Ps[i%2], s.NewG(func() {
+			for range 20 {
+				s.Yield()
+			}
+		}, false))
+	}
+	var sys []*G
+	for range 3 {
+		g, err := s.SubmitSystem(func() {})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sys = append(sys, g)
+	}
+
+	// One round of all three Ms per system G is enough.
+	for range 3 * len(s.Ms) {
+		s.Step()
+	}
+	for _, g := range sys {
+		if g.Status() != Done {
+			t.Fatalf("system G%d is %v with user Ps saturated", g.ID, g.Status())
+		}
+	}
+	if st := s.Stats(); st.Completed != len(sys) {
+		t.Fatalf("Completed = %d, want only the %d system Gs", st.Completed, len(sys))
+	}
+
+	s.RunDeterministic()
+	for _, e := range s.DumpTrace() {
+		isSys := e.GID >= sys[0].ID
+		if e.Kind == EventStart && isSys != (e.PID == s.sysP.ID) {
+			t.Fatalf("G%d started on P%d", e.GID, e.PID)
+		}
+		if e.Kind == EventSteal && (e.From == s.sysP.ID || e.PID == s.sysP.ID) {
+			t.Fatalf("steal involving the system P: %v", e)
+		}
+	}
+}
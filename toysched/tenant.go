@@ -0,0 +1,127 @@
+package toysched
+
+import "slices"
+
+// WithTenantWeights turns on weighted fair queuing across tenants (see
+// G.Tenant). Each P, globalQ and every steal then dequeue by deficit
+// round robin over their tenants: a tenant's turn runs up to its weight
+// in Gs before the next tenant's turn, so over time tenants share the
+// Ms in proportion to their weights rather than in FIFO order. Tenants
+// missing from weights get weight 1.
+func WithTenantWeights(weights map[int]int) Option {
+	return func(s *Scheduler) {
+		s.tenantWeights = make(map[int]int, len(weights))
+		for t, w := range weights {
+			s.tenantWeights[t] = w
+		}
+	}
+}
+
+// weight is the tenant's DRR quantum, at least 1.
+func (s *Scheduler) weight(tenant int) int {
+	return max(s.tenantWeights[tenant], 1)
+}
+
+// drr is a deficit round robin cursor over the tenants of one queue:
+// whose turn it is and how many more Gs that tenant may take this turn.
+type drr struct {
+	tenant int
+	left   int
+}
+
+// pick returns the index in q of the next G to take by deficit round
+// robin, considering only Gs ok accepts (every G if ok is nil), or -1 if
+// there is none, and charges it to its tenant's turn.
+func (d *drr) pick(s *Scheduler, q []*G, ok func(*G) bool) int {
+	var tenants []int
+	for _, g := range q {
+		if (ok == nil || ok(g)) && !slices.Contains(tenants, g.Tenant) {
+			tenants = append(tenants, g.Tenant)
+		}
+	}
+	if len(tenants) == 0 {
+		return -1
+	}
+	slices.Sort(tenants)
+
+	// Whose turn: the current tenant if it still has Gs, else the next
+	// one in ID order, wrapping around.
+	i, _ := slices.BinarySearch(tenants, d.tenant)
+	if i == len(tenants) {
+		i = 0
+	}
+	t := tenants[i]
+	if t != d.tenant || d.left <= 0 {
+		d.tenant = t
+		d.left = s.weight(t)
+	}
+
+	// Highest priority within the tenant, FIFO among equals.
+	best := -1
+	for j, g := range q {
+		if g.Tenant == t && (ok == nil || ok(g)) && (best < 0 || g.Priority > q[best].Priority) {
+			best = j
+		}
+	}
+
+	d.left--
+	if d.left == 0 {
+		// Turn used up: start from the next tenant next time.
+		d.tenant = t + 1
+	}
+	return best
+}
+
+// popLocked takes the next G to run from p: by priority and FIFO, or by
+// deficit round robin across tenants when weights are set.
+// Caller must hold s.mu.
+func (s *Scheduler) popLocked(p *P) *G {
+	if s.DisableOverflow {
+		// Room on p for a Submit waiting in placeLocked.
+		defer s.space.Broadcast()
+	}
+	if s.tenantWeights == nil || p.NumG == 0 {
+		return p.pop()
+	}
+	best := p.drr.pick(s, p.RunQ, nil)
+	g := p.RunQ[best]
+	p.RunQ = append(p.RunQ[:best], p.RunQ[best+1:]...)
+	p.NumG--
+	return g
+}
+
+// takeGlobalLocked removes and returns the next G from globalQ: the
+// head, or with tenant weights the deficit round robin pick, so Gs that
+// overflowed are shared out by weight too. Caller must hold s.mu.
+func (s *Scheduler) takeGlobalLocked() *G {
+	i := 0
+	if s.tenantWeights != nil {
+		i = s.globalDRR.pick(s, s.globalQ, nil)
+	}
+	g := s.globalQ[i]
+	s.globalQ = append(s.globalQ[:i], s.globalQ[i+1:]...)
+	s.space.Broadcast()
+	return g
+}
+
+// takeStolenLocked removes n unpinned Gs from victim's queue for a
+// thief: the oldest, or with tenant weights the next n in the victim's
+// deficit round robin order, so stealing keeps the weighted shares.
+// Caller must hold s.mu.
+func (s *Scheduler) takeStolenLocked(victim *P, n int) []*G {
+	if s.tenantWeights == nil {
+		stolen, kept := takeUnpinned(victim.RunQ, n)
+		victim.RunQ = kept
+		victim.NumG -= len(stolen)
+		return stolen
+	}
+	unpinned := func(g *G) bool { return g.PinnedP < 0 }
+	stolen := make([]*G, 0, n)
+	for range n {
+		i := victim.drr.pick(s, victim.RunQ, unpinned)
+		stolen = append(stolen, victim.RunQ[i])
+		victim.RunQ = append(victim.RunQ[:i], victim.RunQ[i+1:]...)
+	}
+	victim.NumG -= n
+	return stolen
+}
@@ -0,0 +1,84 @@
+package toysched
+
+import "testing"
+
+func TestTenantWeightsShareOverflowAndSteals(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights map[int]int
+		// Share of the first half of completions going to tenant 1.
+		min, max float64
+	}{
+		{"3:1", map[int]int{1: 3, 2: 1}, 0.70, 0.80},
+		{"1:1", map[int]int{1: 1, 2: 1}, 0.45, 0.55},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, 2, 2, WithTenantWeights(tt.weights))
+			const n = 200
+			var order []int
+			for i := range n {
+				tenant := 1 + i%2
+				g := s.NewG(func() { order = append(order, tenant) }, false)
+				g.Tenant = tenant
+				// Everything on one P: most of it overflows to globalQ
+				// and the other M has to steal.
+				if err := s.Enqueue(s.Ps[0], g); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s.RunDeterministic()
+			if len(order) != n {
+				t.Fatalf("%d of %d Gs ran", len(order), n)
+			}
+			first := 0
+			for _, tenant := range order[:n/2] {
+				if tenant == 1 {
+					first++
+				}
+			}
+			if share := float64(first) / (n / 2); share < tt.min || share > tt.max {
+				t.Fatalf("tenant 1 got %.2f of the first %d runs, want %.2f-%.2f", share, n/2, tt.min, tt.max)
+			}
+			if st := s.Stats(); st.Steals == 0 {
+				t.Fatal("no steals; the test no longer covers globalQ")
+			}
+		})
+	}
+}
+
+func TestTenantCompletedInFixedWindow(t *testing.T) {
+	tests := []struct {
+		name         string
+		weights      map[int]int
+		want1, want2 int // completions per tenant after the window
+		tolerance    int
+	}{
+		{"3:1", map[int]int{1: 3, 2: 1}, 30, 10, 1},
+		{"1:3", map[int]int{1: 1, 2: 3}, 10, 30, 1},
+		{"unweighted is FIFO", nil, 20, 20, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []Option
+			if tt.weights != nil {
+				opts = append(opts, WithTenantWeights(tt.weights))
+			}
+			s := newDeterministic(t, 1, 1, append(opts, WithLocalQueueCap(1000))...)
+			for i := range 200 {
+				g := s.NewG(func() {}, false)
+				g.Tenant = 1 + i%2
+				s.Enqueue(s.Ps[0], g)
+			}
+			for range 40 {
+				s.Step()
+			}
+
+			done := s.Stats().TenantCompleted
+			diff := func(a, b int) int { return max(a-b, b-a) }
+			if diff(done[1], tt.want1) > tt.tolerance || diff(done[2], tt.want2) > tt.tolerance {
+				t.Fatalf("TenantCompleted = %v, want about 1:%d 2:%d", done, tt.want1, tt.want2)
+			}
+		})
+	}
+}
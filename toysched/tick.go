@@ -0,0 +1,45 @@
+package toysched
+
+import "time"
+
+// sleepAdaptive sleeps m between polling rounds under AdaptiveTick:
+// MinTick after a round that ran a G, otherwise twice the last interval,
+// capped at MaxTick. A wakeMs signal cuts the sleep short and resets the
+// interval. Reports false if the scheduler stopped meanwhile.
+func (m *M) sleepAdaptive(s *Scheduler, worked bool) bool {
+	d := time.Duration(m.interval.Load())
+	if worked || d <= 0 {
+		d = s.MinTick
+	} else {
+		d = min(2*d, s.MaxTick)
+	}
+	m.interval.Store(int64(d))
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-m.stop:
+		return false
+	case <-s.ctx.Done():
+		return false
+	case <-m.wake:
+		m.interval.Store(int64(s.MinTick))
+	case <-t.C:
+	}
+	return true
+}
+
+// tickInterval is how long m currently sleeps between polling rounds,
+// or 0 for an EventDriven M.
+func (m *M) tickInterval(s *Scheduler) time.Duration {
+	switch {
+	case s.EventDriven:
+		return 0
+	case !s.AdaptiveTick:
+		return s.TickInterval
+	}
+	if d := time.Duration(m.interval.Load()); d > 0 {
+		return d
+	}
+	return s.MinTick
+}
@@ -0,0 +1,131 @@
+package toysched
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTickIntervalByMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		evented  bool
+		adaptive bool
+		interval time.Duration // m.interval before the call
+		want     time.Duration
+	}{
+		{"fixed", false, false, 0, 25 * time.Millisecond},
+		{"event-driven", true, false, 0, 0},
+		{"adaptive before first round", false, true, 0, time.Millisecond},
+		{"adaptive backed off", false, true, 40 * time.Millisecond, 40 * time.Millisecond},
+		{"event-driven wins over adaptive", true, true, 40 * time.Millisecond, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := NewScheduler(1, 1, WithLogger(nil))
+			s.TickInterval = 25 * time.Millisecond
+			s.EventDriven = tt.evented
+			s.AdaptiveTick = tt.adaptive
+			m := s.Ms[0]
+			m.interval.Store(int64(tt.interval))
+			if got := m.tickInterval(s); got != tt.want {
+				t.Fatalf("tickInterval = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSleepAdaptiveBacksOff(t *testing.T) {
+	s, _ := NewScheduler(1, 1, WithLogger(nil))
+	s.AdaptiveTick = true
+	s.MinTick, s.MaxTick = time.Microsecond, 8*time.Microsecond
+	m := s.Ms[0]
+	steps := []struct {
+		worked bool
+		want   time.Duration
+	}{
+		{false, 1 * time.Microsecond}, // nothing before: start at MinTick
+		{false, 2 * time.Microsecond},
+		{false, 4 * time.Microsecond},
+		{false, 8 * time.Microsecond},
+		{false, 8 * time.Microsecond}, // capped at MaxTick
+		{true, 1 * time.Microsecond},  // ran a G: back to MinTick
+		{false, 2 * time.Microsecond},
+	}
+	for i, st := range steps {
+		if !m.sleepAdaptive(s, st.worked) {
+			t.Fatal("sleepAdaptive reported a stop")
+		}
+		if got := m.tickInterval(s); got != st.want {
+			t.Fatalf("round %d (worked %v): interval %v, want %v", i, st.worked, got, st.want)
+		}
+	}
+}
+
+func TestAdaptiveTickIdleThenBusy(t *testing.T) {
+	s, _ := NewScheduler(1, 1, WithLogger(nil))
+	s.AdaptiveTick = true
+	s.MinTick, s.MaxTick = time.Millisecond, 16*time.Millisecond
+	s.ParkCooldown = 0
+	s.Run()
+	defer s.Stop()
+
+	interval := func() time.Duration { return s.Stats().MTickIntervals[0] }
+	deadline := time.Now().Add(2 * time.Second)
+	for interval() < s.MaxTick {
+		if time.Now().After(deadline) {
+			t.Fatalf("idle M's interval stuck at %v, want %v", interval(), s.MaxTick)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	g, err := s.Submit(func() {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Wait()
+	// The wakeup and the G reset it; at most a round or two of doubling since.
+	if got := interval(); got > 4*s.MinTick {
+		t.Fatalf("interval %v after work arrived, want back near %v", got, s.MinTick)
+	}
+}
+
+// submitLatency is the mean WaitLatency of Gs submitted one at a time to
+// an otherwise idle scheduler.
+func submitLatency(t *testing.T, evented bool) time.Duration {
+	t.Helper()
+	s, _ := NewScheduler(1, 1, WithLogger(nil))
+	s.TickInterval = 40 * time.Millisecond
+	s.ParkCooldown = 0
+	s.EventDriven = evented
+	s.Run()
+	defer s.Stop()
+
+	var total time.Duration
+	const n = 5
+	for range n {
+		// Let the M go idle so the next G has to wake it.
+		time.Sleep(15 * time.Millisecond)
+		g, err := s.Submit(func() {})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := g.WaitContext(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		total += g.WaitLatency()
+	}
+	return total / n
+}
+
+func TestEventDrivenWakesFasterThanPolling(t *testing.T) {
+	polled := submitLatency(t, false)
+	evented := submitLatency(t, true)
+	t.Logf("mean wait: polling %v, event-driven %v", polled, evented)
+	if evented >= polled {
+		t.Fatalf("event-driven wait %v is no better than polling %v", evented, polled)
+	}
+	if evented > 10*time.Millisecond {
+		t.Fatalf("event-driven wait %v, want well under a 40ms tick", evented)
+	}
+}
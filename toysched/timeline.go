@@ -0,0 +1,125 @@
+package toysched
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Number of time slices across a HandoffTimeline.
+const timelineWidth = 60
+
+// Timeline cells, besides a running G's P digit.
+const (
+	cellParked  = '.'
+	cellHolding = '-'
+	cellSyscall = 'S'
+	cellUnknown = ' '
+)
+
+// HandoffTimeline renders the events in DumpTrace as a Gantt-style chart,
+// one row per M, the span from the first to the last event cut into
+// timelineWidth equal slices. A cell shows the P an M ran a G on at any
+// point in that slice (as a digit, # past P9), and otherwise what the M
+// was doing at the slice's end: - holding a P with nothing to run, S
+// stuck in a syscall with a blocked G, . parked without a P. Before its
+// first event an M's row is blank. Handoff churn shows up as rows
+// flickering between - and ., and a blocking G as an S run next to
+// another M picking up its P.
+func (s *Scheduler) HandoffTimeline() string {
+	events := s.DumpTrace()
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	s.mu.Lock()
+	mIDs := make([]int, 0, len(s.Ms))
+	for _, m := range s.Ms {
+		mIDs = append(mIDs, m.ID)
+	}
+	s.mu.Unlock()
+
+	if len(events) == 0 {
+		return "(no events)\n"
+	}
+	start := events[0].Time
+	span := events[len(events)-1].Time.Sub(start)
+	slice := max((span+timelineWidth-1)/timelineWidth, time.Nanosecond)
+
+	// What each M is doing (0 before its first event, 'R' running a G
+	// on the P in digit) and its row so far.
+	type mRow struct {
+		state, digit, ran byte
+		cells             []byte
+	}
+	rows := make(map[int]*mRow, len(mIDs))
+	for _, id := range mIDs {
+		rows[id] = &mRow{cells: []byte(strings.Repeat(string(cellUnknown), timelineWidth))}
+	}
+	i := 0
+	for col := range timelineWidth {
+		end := start.Add(time.Duration(col+1) * slice)
+		for _, r := range rows {
+			r.ran = 0
+			if r.state == 'R' {
+				r.ran = r.digit
+			}
+		}
+		for ; i < len(events) && !events[i].Time.After(end); i++ {
+			e := events[i]
+			r, ok := rows[e.MID]
+			if !ok {
+				continue
+			}
+			if c, p := timelineState(e); c != 0 {
+				r.state = c
+				if c == 'R' {
+					r.digit = pDigit(p)
+					r.ran = r.digit
+				}
+			}
+		}
+		for _, r := range rows {
+			if r.ran != 0 {
+				r.cells[col] = r.ran
+			} else if r.state != 0 {
+				r.cells[col] = r.state
+			}
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v over %d slices of %v\n", span, timelineWidth, slice)
+	for _, id := range mIDs {
+		fmt.Fprintf(&b, "M%-3d|%s|\n", id, rows[id].cells)
+	}
+	b.WriteString("0-9 running a G on that P, - holding a P idle, S in a syscall, . parked\n")
+	return b.String()
+}
+
+// timelineState is the cell an M is left in after e, 'R' meaning it is
+// running a G on P p, or 0 if e doesn't change it.
+func timelineState(e SchedEvent) (byte, int) {
+	switch e.Kind {
+	case EventStart, EventResume:
+		return 'R', e.PID
+	case EventYield, EventPreempt, EventWait, EventFinish, EventFail, EventGrab, EventSteal:
+		return cellHolding, e.PID
+	case EventBlock, EventSyscall:
+		return cellSyscall, e.PID
+	case EventPark:
+		return cellParked, -1
+	case EventUnblock:
+		if e.PID < 0 {
+			return cellParked, -1
+		}
+		return cellHolding, e.PID
+	}
+	return 0, -1
+}
+
+// pDigit is the timeline cell for running on P id.
+func pDigit(id int) byte {
+	if id >= 0 && id < 10 {
+		return byte('0' + id)
+	}
+	return '#'
+}
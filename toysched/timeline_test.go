@@ -0,0 +1,74 @@
+package toysched
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestHandoffTimelineShowsPark(t *testing.T) {
+	s := newDeterministic(t, 1, 2)
+	s.Enqueue(s.Ps[0], s.NewGBlocking(func() {}, 3*s.TickInterval))
+	fillP(s, s.Ps[0], 4)
+	s.RunDeterministic()
+	out := s.HandoffTimeline()
+
+	rowRE := regexp.MustCompile(`^(M\d+) *\|(.*)\|$`)
+	rows := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		if m := rowRE.FindStringSubmatch(line); m != nil {
+			rows[m[1]] = m[2]
+		}
+	}
+	tests := []struct {
+		m    string
+		want string // regexp over the whole row
+	}{
+		// Runs G0, sits in the syscall, then parks: G0 went to globalQ.
+		{"M0", `^0S+\.+$`},
+		// Picks up P0 and runs everything else, never parking.
+		{"M1", `^0[-0]+0$`},
+	}
+	for _, tt := range tests {
+		row, ok := rows[tt.m]
+		if !ok {
+			t.Fatalf("no row for %s:\n%s", tt.m, out)
+		}
+		if len(row) != timelineWidth {
+			t.Fatalf("%s row is %d cells, want %d", tt.m, len(row), timelineWidth)
+		}
+		if !regexp.MustCompile(tt.want).MatchString(row) {
+			t.Errorf("%s row %q doesn't match %s", tt.m, row, tt.want)
+		}
+	}
+}
+
+func TestHandoffTimelineEmpty(t *testing.T) {
+	s := newDeterministic(t, 1, 1)
+	if got := s.HandoffTimeline(); got != "(no events)\n" {
+		t.Fatalf("HandoffTimeline() = %q", got)
+	}
+}
+
+func TestTimelineState(t *testing.T) {
+	tests := []struct {
+		e    SchedEvent
+		cell byte
+		onP  int
+	}{
+		{SchedEvent{Kind: EventStart, PID: 2}, 'R', 2},
+		{SchedEvent{Kind: EventResume, PID: 1}, 'R', 1},
+		{SchedEvent{Kind: EventFinish, PID: 1}, cellHolding, 1},
+		{SchedEvent{Kind: EventGrab, PID: 0}, cellHolding, 0},
+		{SchedEvent{Kind: EventBlock, PID: 0}, cellSyscall, 0},
+		{SchedEvent{Kind: EventPark, PID: 3}, cellParked, -1},
+		{SchedEvent{Kind: EventUnblock, PID: -1}, cellParked, -1},
+		{SchedEvent{Kind: EventUnblock, PID: 4}, cellHolding, 4},
+		{SchedEvent{Kind: EventReady, PID: 0}, 0, -1},
+	}
+	for _, tt := range tests {
+		if cell, p := timelineState(tt.e); cell != tt.cell || p != tt.onP {
+			t.Errorf("timelineState(%v) = %q, %d; want %q, %d", tt.e.Kind, cell, p, tt.cell, tt.onP)
+		}
+	}
+}
@@ -0,0 +1,1696 @@
+// Package toysched is a toy model of the Go runtime scheduler built from the
+// step-by-step demos in this module. It wires up Gs (goroutines), Ps
+// (processors) and Ms (machines) so the scheduler can be embedded in tests
+// and other programs instead of living inside a single main.
+package toysched
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"os"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// GStatus is the lifecycle state of a G.
+type GStatus int
+
+const (
+	// Queued on a P (or globalQ), waiting for an M.
+	Runnable GStatus = iota
+	// Currently executing on an M.
+	Running
+	// Waiting on its block channel (syscall sim); its M waits too.
+	Blocked
+	// Parked on a scheduler primitive (e.g. SchedChan); its M moved on.
+	Waiting
+	// Finished.
+	Done
+	// Func panicked, or NewGErr's function returned an error, on the
+	// last attempt; see G.Err.
+	Failed
+	// Withdrawn by Cancel before it ever ran.
+	Cancelled
+)
+
+func (st GStatus) String() string {
+	switch st {
+	case Runnable:
+		return "runnable"
+	case Running:
+		return "running"
+	case Blocked:
+		return "blocked"
+	case Done:
+		return "done"
+	case Failed:
+		return "failed"
+	case Waiting:
+		return "waiting"
+	case Cancelled:
+		return "cancelled"
+	}
+	return fmt.Sprintf("GStatus(%d)", int(st))
+}
+
+// The default placement threshold: a P holding more than this many Gs
+// is full, and new Gs overflow to globalQ.
+const overflowThreshold = 5
+
+// Default anti-thrash cooldown after an M parks (see ParkCooldown). It
+// doubles for every consecutive grab that found an empty P, up to
+// maxCooldownFactor times the base.
+const (
+	parkCooldown      = 200 * time.Millisecond
+	maxCooldownFactor = 10
+)
+
+// Where G represents a Goroutine
+type G struct {
+	// Unique ID
+	ID int
+
+	// Function to be ran
+	Func func()
+	// Set instead of Func by NewGErr; a non-nil error fails the G.
+	errFunc func() error
+	// Whether to re-run the function after it fails, and how many runs
+	// it has had so far.
+	Retry    RetryPolicy
+	Attempts int
+
+	// Higher runs first; FIFO within the same priority.
+	Priority int
+	// ID of the only P the G may run on, or -1 if it may run anywhere;
+	// see NewGPinned. Set before queueing.
+	PinnedP int
+	// Who the G runs on behalf of, for WithTenantWeights. 0 by default.
+	Tenant int
+
+	// When the G last became runnable (queued), for the fairness guard.
+	readyAt time.Time
+	// The P the G last ran on; readyLocked requeues it there.
+	lastP *P
+	// Set by gopark; run by the M under s.mu to finish parking.
+	commit func() bool
+	// Set by Gosched: requeue on globalQ rather than the local P.
+	global bool
+
+	// How long the G may run in one go before it is preempted; see
+	// NewGPreemptible. 0 means no limit.
+	TimeBudget time.Duration
+	// When the current run began, and whether the G parked because its
+	// budget ran out (rather than yielding).
+	sliceStart time.Time
+	preempted  bool
+	// When the G last blocked, for sysmon.
+	blockedAt time.Time
+
+	// Runnable, Running, Blocked, ... (see GStatus). Atomic because the G's
+	// goroutine, its M and callers all touch it; see Status.
+	status atomic.Int32
+
+	// Why the last attempt failed (status is Failed): a *PanicError,
+	// or the error NewGErr's function returned.
+	Err error
+	// What NewGErr's function returned on its last attempt; nil if it
+	// succeeded or panicked. Read after Wait.
+	Result error
+
+	// When the G was created, first started running, and finished
+	// (Done or Failed). Zero until reached; read after Wait.
+	CreatedAt  time.Time
+	StartedAt  time.Time
+	FinishedAt time.Time
+
+	// If non-nil, the G blocks after Func until signalled (syscall sim).
+	// Signalled at most once, guarded by unblockOnce.
+	blockChan   chan struct{}
+	unblockOnce sync.Once
+	// If non-zero, the G unblocks itself this long after blocking.
+	blockFor time.Duration
+	// If non-nil, the G unblocks once this caller-owned channel fires.
+	wake <-chan struct{}
+
+	// Closed once the G is Done, Failed or Cancelled.
+	done     chan struct{}
+	doneOnce sync.Once
+	// OnDone callbacks, and whether they have already been run.
+	cbMu      sync.Mutex
+	callbacks []func(*G)
+	finished  bool
+
+	// The G that spawned this one, the Gs it has spawned, and how many
+	// of those have not finished; see Spawn. Guarded by sched.mu.
+	parent          *G
+	children        []*G
+	pendingChildren int
+
+	// Owning scheduler, so Func can Yield.
+	sched *Scheduler
+	// Func runs on its own goroutine; the M resumes it via resume
+	// and waits on parked until it yields or finishes. started is set
+	// by the M without s.mu, while Cancel reads it under s.mu.
+	started atomic.Bool
+	resume  chan struct{}
+	parked  chan struct{}
+}
+
+// WaitLatency is how long the G sat queued before it first ran, or 0
+// if it hasn't started.
+func (g *G) WaitLatency() time.Duration {
+	if g.StartedAt.IsZero() {
+		return 0
+	}
+	return g.StartedAt.Sub(g.CreatedAt)
+}
+
+// RunDuration is the time from the G's first run to its finish,
+// including any time spent yielded or blocked, or 0 if it hasn't
+// finished.
+func (g *G) RunDuration() time.Duration {
+	if g.FinishedAt.IsZero() {
+		return 0
+	}
+	return g.FinishedAt.Sub(g.StartedAt)
+}
+
+// Status reports the G's current lifecycle state.
+func (g *G) Status() GStatus {
+	return GStatus(g.status.Load())
+}
+
+func (g *G) setStatus(st GStatus) {
+	g.status.Store(int32(st))
+}
+
+// PanicError records a panic recovered from a G's Func.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("toysched: G panicked: %v", e.Value)
+}
+
+// Run starts (or resumes) Func on the G's own goroutine and waits until
+// it finishes or yields the M.
+func (g *G) Run() {
+	if !g.started.Load() {
+		g.started.Store(true)
+		go g.main()
+	} else {
+		g.resume <- struct{}{}
+	}
+	<-g.parked
+}
+
+// main is the body of the G's goroutine.
+func (g *G) main() {
+	g.sched.register(g)
+	defer func() {
+		g.sched.unregister()
+		g.parked <- struct{}{}
+	}()
+
+	// May block inside!
+	for {
+		g.Attempts++
+		err := g.call()
+		if err == nil {
+			break
+		}
+		if g.Attempts < g.Retry.MaxAttempts {
+			g.sched.printf("Goroutine attempt %d failed: %v\n", g.Attempts, err)
+			g.sched.SleepG(g.Retry.Backoff)
+			continue
+		}
+		g.Err = err
+		g.FinishedAt = g.sched.now()
+		g.setStatus(Failed)
+		if perr, ok := err.(*PanicError); ok {
+			g.sched.printf("Goroutine panicked: %v\n", perr.Value)
+		} else {
+			g.sched.printf("Goroutine failed: %v\n", err)
+		}
+		g.finish()
+		return
+	}
+	if g.blockChan != nil {
+		g.setStatus(Blocked)
+		g.sched.printf("  G: Waiting for unblock signal...\n")
+		// Block here: the M hands off its P and waits with us.
+		g.park()
+		g.sched.printf("  G: Resumed after unblock!\n")
+	}
+	g.FinishedAt = g.sched.now()
+	g.setStatus(Done)
+	g.sched.printf("Goroutine is done with task!\n")
+	g.finish()
+}
+
+// park hands the M back and waits to be resumed.
+func (g *G) park() {
+	g.parked <- struct{}{}
+	<-g.resume
+}
+
+// finish counts the G as Done or Failed, runs the OnDone callbacks and
+// then closes the done channel, at most once. Counting first means a
+// caller woken by Wait already sees it in Stats and under MaxGs.
+// Callers must not hold s.mu.
+func (g *G) finish() {
+	g.doneOnce.Do(func() {
+		g.sched.countFinished(g)
+		g.cbMu.Lock()
+		g.finished = true
+		cbs := g.callbacks
+		g.callbacks = nil
+		g.cbMu.Unlock()
+		for _, cb := range cbs {
+			cb(g)
+		}
+		g.sched.complete(g)
+		close(g.done)
+	})
+}
+
+// countFinished adds a Done or Failed G to the cumulative counters.
+// Cancel and startAfter count Cancelled Gs themselves.
+func (s *Scheduler) countFinished(g *G) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch g.Status() {
+	case Done:
+		s.completed++
+		s.tenantDone[g.Tenant]++
+		s.waitLatencies.add(g.WaitLatency())
+	case Failed:
+		s.failed++
+	}
+}
+
+// OnDone registers cb to be called with g once it is Done, Failed or
+// Cancelled, so fire-and-forget work can react to results without a
+// Wait. Callbacks run in registration order, exactly once, on the G's
+// own goroutine (or Cancel's caller) and without the scheduler lock
+// held, so they may submit new Gs. Wait returns only once they have all
+// run, so a callback must not Wait on g itself. If g has already
+// finished, cb runs straight away on the caller.
+func (g *G) OnDone(cb func(*G)) {
+	g.cbMu.Lock()
+	if !g.finished {
+		g.callbacks = append(g.callbacks, cb)
+		g.cbMu.Unlock()
+		return
+	}
+	g.cbMu.Unlock()
+	cb(g)
+}
+
+// Wait blocks until the G is Done, Failed or Cancelled.
+func (g *G) Wait() {
+	<-g.done
+}
+
+// WaitContext is like Wait but gives up when ctx is done.
+func (g *G) WaitContext(ctx context.Context) error {
+	select {
+	case <-g.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// call runs the G's function once, recovering a panic as a *PanicError
+// so it can't take down the M.
+func (g *G) call() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	if g.errFunc != nil {
+		g.Result = nil
+		g.Result = g.errFunc()
+		return g.Result
+	}
+	g.Func()
+	return nil
+}
+
+// Where P represents a Processor (logical CPU)
+type P struct {
+	ID int
+
+	// Local run queue of Gs
+	RunQ []*G
+
+	// Current number of Gs in the queue
+	NumG int
+
+	// If positive, the most Gs Enqueue and Submit put here. A G arriving
+	// at a full P goes to globalQ along with the older half of the queue.
+	// 0 leaves overflow to the placement policy's threshold.
+	Cap int
+
+	// Set by SetNumP when the P is removed; guarded by s.mu.
+	retired bool
+	// Wall-clock nanoseconds Gs have run on this P, for Benchmark.
+	busy atomic.Int64
+
+	// Deficit round robin state for tenant weights.
+	drr drr
+}
+
+// push appends g to the tail of the run queue. Caller must hold s.mu.
+func (p *P) push(g *G) {
+	p.RunQ = append(p.RunQ, g)
+	p.NumG++
+}
+
+// pop removes the oldest G of the highest priority in the run queue.
+// Caller must hold s.mu.
+func (p *P) pop() *G {
+	if p.NumG == 0 {
+		return nil
+	}
+	best := 0
+	for i, g := range p.RunQ {
+		if g.Priority > p.RunQ[best].Priority {
+			best = i
+		}
+	}
+	g := p.RunQ[best]
+	p.RunQ = append(p.RunQ[:best], p.RunQ[best+1:]...)
+	p.NumG--
+	return g
+}
+
+// Where M represents a Machine (OS thread)
+type M struct {
+	ID int
+
+	// P bound to the Machine (if any)
+	P *P
+
+	// Current G being run (if any)
+	G *G
+
+	// To stop M's goroutine.
+	stop chan struct{}
+	// Guards close(stop) so Stop can be called more than once.
+	stopOnce sync.Once
+
+	// When the M last parked, for the anti-thrash cooldown.
+	parkTime time.Time
+	// The P this M last released, for soft affinity on the next grab.
+	lastP *P
+	// Grabs in a row that found nothing to run; grows the cooldown.
+	emptyGrabs int
+
+	// Wake-up token for event-driven mode (buffered, size 1).
+	wake chan struct{}
+
+	// Current MState, and rounds spent in each state. Atomic so Stats
+	// can read them while the M runs.
+	state atomic.Int32
+	ticks [numMStates]atomic.Int64
+	// Whether this round ran a G, for tick.
+	ran bool
+	// Wall-clock nanoseconds spent inside G runs, for MUtilization.
+	busy atomic.Int64
+	// Whether this M counts towards Scheduler.spinning; see spin.
+	spinning bool
+	// When an M whose G was unblocked may go for a P; see ResumeJitter.
+	resumeAt time.Time
+	// The M's current sleep between rounds, in nanoseconds; see
+	// AdaptiveTick.
+	interval atomic.Int64
+}
+
+type Scheduler struct {
+	Ps []*P
+	Ms []*M
+
+	// How long an M sleeps between scheduling rounds. Set before Run.
+	TickInterval time.Duration
+	// If set, a polling M's sleep between rounds adapts instead of being
+	// TickInterval: MinTick after a round that ran a G or a wakeMs
+	// signal, doubling for each empty round in a row up to MaxTick.
+	// MinTick and MaxTick are 1ms and 100ms by default. Set before Run.
+	AdaptiveTick     bool
+	MinTick, MaxTick time.Duration
+	// How long an M that parked waits before trying to grab a P again,
+	// doubled for each grab in a row that found nothing to run, up to
+	// 10x. It stops Ms thrashing between grab and park. 200ms by
+	// default; 0 disables it.
+	ParkCooldown time.Duration
+	// If set, Ms that find no work wait to be signalled (new G,
+	// blocked handoff, unblock) instead of polling every TickInterval.
+	EventDriven bool
+	// Once a globalQ G has waited this long, the next M to look takes
+	// it ahead of its own local queue. 0 disables the guard.
+	GlobalWaitLimit time.Duration
+	// Once any runnable G, in globalQ or a P's queue, has waited this
+	// long, the next M to look runs it ahead of everything else on its
+	// P, whatever its priority. 0 disables the guard.
+	StarvationThreshold time.Duration
+	// If positive, an M whose G is unblocked while it has no P waits a
+	// random delay up to this long before trying to grab one, so that
+	// many Gs woken at once don't all go for availPs in the same tick.
+	ResumeJitter time.Duration
+	// If non-zero, an M whose G blocks keeps its P, and the sysmon
+	// goroutine retakes the P once the G has been blocked this long.
+	// 0 hands the P off as soon as the G blocks. Set before Run.
+	SysmonThreshold time.Duration
+	// If positive, globalQ holds at most this many Gs. When it is full,
+	// Enqueue and Submit wait for room if BlockOnFull is set, and fail
+	// with ErrQueueFull otherwise. Waiting from inside a G stalls its M.
+	GlobalQueueCap int
+	BlockOnFull    bool
+	// If set, a G never spills from a full P to globalQ: Enqueue and
+	// Submit wait for room on a P if BlockOnFull is set, and fail with
+	// ErrQueueFull otherwise. A P is full once the placement policy
+	// says so or it reaches its Cap. Ignored when Centralized.
+	DisableOverflow bool
+	// If positive, Submit, SubmitErr and SubmitSystem fail with
+	// ErrTooManyGs while this many Gs are live: created and not yet
+	// Done, Failed or Cancelled. Gs made directly with NewG (and
+	// friends) aren't refused but do count.
+	MaxGs int
+	// If set, every stretch a G runs is recorded for WriteTrace. The
+	// record grows with every run, so leave it off for long runs.
+	Tracing bool
+	// If positive, a watchdog reports a deadlock once every M has been
+	// parked this many ticks in a row with Gs still queued: through
+	// OnDeadlock if set, otherwise by panicking. 0 means 50 ticks when
+	// OnDeadlock is set, and no watchdog otherwise. Set before Run.
+	DeadlockTicks int
+	// How much an M with an empty queue takes from a peer P's queue.
+	StealPolicy StealPolicy
+	// If positive, at most this many idle Ms poll for work every
+	// TickInterval; the rest sleep until new work is signalled. 0 lets
+	// every idle M poll. EventDriven Ms sleep when idle anyway.
+	MaxSpinning int
+	// If set, per-P run queues go unused: every runnable G goes to
+	// globalQ and Ms always take from there, as in a single-queue
+	// scheduler. Pinned Gs and the system P still use their own queues.
+	// For contrast with the per-P design; see cmd/bench.
+	Centralized bool
+	// Which peer P an M with an empty queue steals from. nil means
+	// MostLoaded, or a random peer when seeded (see WithSeed).
+	VictimSelector VictimSelector
+
+	// For safe ID allocation
+	mu schedMutex
+	// Signalled (on mu) whenever a G finishes, for WaitIdle.
+	idle *sync.Cond
+	// Signalled (on mu) whenever a G leaves globalQ, or with
+	// DisableOverflow a P's queue, for BlockOnFull.
+	space *sync.Cond
+	// Global counter for G IDs
+	nextGID int
+	// Grab attempts skipped for the park cooldown, for Stats. Atomic as
+	// the check runs without s.mu.
+	cooldownSkips atomic.Int64
+	// Submits past the MaxGs check that haven't created their G yet.
+	reservedGs int
+	// For work-stealing if local empty.
+	globalQ []*G
+	// Wait for all Ms.
+	wg sync.WaitGroup
+	// Central pool for available Ps (buffered to avoid send blocks).
+	availPs chan *P
+	// Called after a G's Func panics.
+	onPanic func(*G, any)
+	// Called, without s.mu held, when an M hands off or grabs a P.
+	onHandoff, onGrab func(mID, pID int)
+	// Called when the scheduler goes idle; inIdleHook is set while it
+	// runs so WaitIdle doesn't return before it can add work.
+	onIdle     func(*Scheduler)
+	inIdleHook bool
+	// Idle Ms currently polling for work, and the signal that wakes
+	// those sleeping past MaxSpinning: spinGen counts wakeMs calls.
+	spinning atomic.Int32
+	spinMu   sync.Mutex
+	spinCond *sync.Cond
+	spinGen  uint64
+	// Deadlock watchdog hook and consecutive stuck ticks seen so far.
+	onDeadlock func(pending int)
+	stuckTicks int
+	// Whether the watchdog goroutine is running.
+	watchdog bool
+	// AllParked's channel, and consecutive fully parked ticks so far.
+	allParked   chan struct{}
+	parkedTicks int
+	// Gs currently executing, by goroutine id (see current).
+	gs map[uint64]*G
+	// Closed by Stop, for goroutines other than the Ms.
+	stop     chan struct{}
+	stopOnce sync.Once
+	// Set once Shutdown begins; Submit then refuses new Gs.
+	shutdown bool
+	// Drives M order in Step and steal victims when seeded; see WithSeed.
+	rng *rand.Rand
+	// G runs recorded while Tracing.
+	runSlices []runSlice
+	// Cap for new Ps; see WithLocalQueueCap.
+	localCap int
+	// Tenant weights (nil if unweighted), completions by tenant, and
+	// the deficit round robin state for globalQ.
+	tenantWeights map[int]int
+	tenantDone    map[int]int
+	globalDRR     drr
+	// Picks a P for Enqueue and Submit; see WithPlacement.
+	placement Placement
+	// Gs waiting on their block channel, by G ID.
+	blockedGs map[int]*G
+	// A bounded sample of the WaitLatency of Gs that finished normally,
+	// for LatencyReport.
+	waitLatencies latencyReservoir
+	// Gs parked via gopark and not yet readied.
+	waiting int
+	// Gs parked by SleepG, earliest wake time first.
+	sleepers sleepHeap
+	// When the pending wakeSleepers check fires; zero if none.
+	sleepCheck time.Time
+	// Gs between dispatch and parking, and the cap on them; see
+	// SetMaxRunning.
+	running, maxRunning int
+	// The P reserved for SubmitSystem, if WithSystemP asked for one.
+	wantSysP bool
+	sysP     *P
+	// Wall-clock time the Ms were started, for MUtilization.
+	startedAt time.Time
+	// When the current StopTheWorld began (zero if the world is
+	// running), and the pauses so far.
+	stwStart time.Time
+	stwCount int
+	stwTotal time.Duration
+	stwMax   time.Duration
+	// Non-nil while paused; closed by Resume.
+	resumed chan struct{}
+	// Registered fds for WaitIO/MarkReady; see RegisterIO.
+	fds map[int]*pollDesc
+	// Cancelling it stops the Ms; passed to NewGCtx Funcs.
+	ctx context.Context
+	// Set once Run has started the M goroutines; AddM then starts its
+	// M too.
+	launched bool
+
+	// Event stream (see Events), and where events and progress lines
+	// are logged; see WithLogger.
+	events chan SchedEvent
+	logger *slog.Logger
+	// Finished Gs, for Completions.
+	completions chan *G
+	// The latest events, for DumpTrace.
+	ring eventRing
+
+	// Cumulative counters for Stats, guarded by mu.
+	created, completed, failed int
+	cancelled                  int
+	steals, handoffs, grabs    int
+	preemptions, promotions    int
+	resumeGrabs                int
+
+	// Deterministic mode (see Step): virtual clock, round-robin cursor
+	// and pending virtual timers.
+	deterministic bool
+	clock         time.Time
+	nextM         int
+	vtimers       []vtimer
+}
+
+// NewScheduler creates numP Ps and numM Ms, binding M i to P i. There
+// may be more Ms than Ps: the extra Ms start parked and must grab a free
+// P before they can run anything. Ms are not started until Run is called.
+func NewScheduler(numP, numM int, opts ...Option) (*Scheduler, error) {
+	if numP <= 0 {
+		return nil, errors.New("toysched: need at least one P")
+	}
+	if numM < 0 {
+		return nil, fmt.Errorf("toysched: invalid M count %d", numM)
+	}
+
+	s := &Scheduler{
+		TickInterval: 10 * time.Millisecond,
+		ParkCooldown: parkCooldown,
+		MinTick:      time.Millisecond,
+		MaxTick:      100 * time.Millisecond,
+		gs:           make(map[uint64]*G),
+		blockedGs:    make(map[int]*G),
+		tenantDone:   make(map[int]int),
+		ctx:          context.Background(),
+		events:       make(chan SchedEvent, eventBuffer),
+		completions:  make(chan *G, completionBuffer),
+		stop:         make(chan struct{}),
+		logger:       newTextLogger(os.Stdout),
+		placement:    Sticky{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.idle = sync.NewCond(&s.mu)
+	s.space = sync.NewCond(&s.mu)
+	s.spinCond = sync.NewCond(&s.spinMu)
+	// Every P starts out available; AddM takes the ones it binds.
+	s.availPs = make(chan *P, numP)
+	for i := 0; i < numP; i++ {
+		s.AddP(i)
+	}
+
+	for i := 0; i < numM; i++ {
+		pIndex := -1
+		if i < numP {
+			pIndex = i
+		}
+		if _, err := s.AddM(i, pIndex); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.wantSysP {
+		s.sysP = &P{ID: numP, RunQ: make([]*G, 0)}
+		m, err := s.AddM(numM, -1)
+		if err != nil {
+			return nil, err
+		}
+		m.P = s.sysP
+		m.setState(MSpinning)
+	}
+	return s, nil
+}
+
+// OnPanic registers a hook called (on the M's goroutine) whenever a G
+// panics. The M keeps its P and carries on scheduling afterwards.
+func (s *Scheduler) OnPanic(f func(*G, any)) {
+	s.onPanic = f
+}
+
+// OnHandoff registers a hook called whenever an M hands its P back to
+// availPs: when it parks with nothing to run, when its G blocks, or when
+// sysmon retakes the P. The scheduler lock is not held, so f may call
+// back into the scheduler.
+func (s *Scheduler) OnHandoff(f func(mID, pID int)) {
+	s.onHandoff = f
+}
+
+// OnIdle registers a hook called (on the M's goroutine, without the
+// scheduler lock held) each time the scheduler goes idle: a G has just
+// finished, every queue is empty, and no G is running, blocked or
+// parked. f may submit more work, and the scheduler carries on with it;
+// WaitIdle, RunAll and Shutdown(Drain) don't return until f has. A G
+// moving between queue and M never looks idle, since the M binds it
+// before it leaves the queue.
+func (s *Scheduler) OnIdle(f func(*Scheduler)) {
+	s.onIdle = f
+}
+
+// OnGrab registers a hook called whenever an idle M takes a P from
+// availPs, including an M returning from a syscall. Like OnHandoff, f
+// runs without the scheduler lock held.
+func (s *Scheduler) OnGrab(f func(mID, pID int)) {
+	s.onGrab = f
+}
+
+// NewG creates a new runnable G with auto-ID.
+func (s *Scheduler) NewG(f func(), block bool) *G {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.nextGID
+	s.nextGID++
+	s.created++
+	g := &G{
+		ID:        id,
+		Func:      f,
+		PinnedP:   -1,
+		CreatedAt: s.now(),
+		done:      make(chan struct{}),
+		sched:     s,
+		resume:    make(chan struct{}),
+		parked:    make(chan struct{}),
+	}
+	if block {
+		// Buffered so Unblock never waits on the M.
+		g.blockChan = make(chan struct{}, 1)
+	}
+	return g
+}
+
+// NewGWithPriority creates a runnable G that is dequeued ahead of any
+// lower-priority G on the same P.
+func (s *Scheduler) NewGWithPriority(f func(), prio int) *G {
+	g := s.NewG(f, false)
+	g.Priority = prio
+	return g
+}
+
+// NewGCtx creates a runnable G whose function receives the scheduler's
+// context (see RunContext), so it can bail out early on cancellation.
+func (s *Scheduler) NewGCtx(f func(context.Context)) *G {
+	return s.NewG(func() { f(s.ctx) }, false)
+}
+
+// NewGBlocking creates a G that, after running f, blocks for d as if in
+// a syscall of known latency and then unblocks itself. While it is
+// blocked its M hands off the P and re-acquires one on resume.
+func (s *Scheduler) NewGBlocking(f func(), d time.Duration) *G {
+	g := s.NewG(f, true)
+	g.blockFor = d
+	return g
+}
+
+// NewGWaiting creates a G that, after running f, blocks until wake
+// receives a value or is closed. The channel belongs to the caller, who
+// signals it instead of calling Unblock with the G's ID. While the G is
+// blocked its M hands off the P, as with NewGBlocking.
+func (s *Scheduler) NewGWaiting(f func(), wake <-chan struct{}) *G {
+	g := s.NewG(f, true)
+	g.wake = wake
+	return g
+}
+
+// AddP creates and adds a P to the scheduler and offers it to idle Ms
+// on availPs, whether or not the scheduler has started; AddM may claim
+// it for a new M instead. Like every access to Ps and Ms it takes the
+// scheduler lock, so it is safe to call while Ms run; read s.Ps
+// directly only before Run or between Steps.
+func (s *Scheduler) AddP(id int) *P {
+	p := &P{
+		ID:   id,
+		RunQ: make([]*G, 0),
+		NumG: 0,
+		Cap:  s.localCap,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Ps = append(s.Ps, p)
+	s.offerPLocked(p)
+	s.wakeMs()
+	return p
+}
+
+// offerPLocked puts the new P p, already in s.Ps, on availPs. The
+// channel grows to hold every P, not just the free ones: an M releasing
+// a held P sends under s.mu, so a full channel would deadlock.
+// Ms only take from availPs under s.mu, so swapping it is safe.
+// Caller must hold s.mu.
+func (s *Scheduler) offerPLocked(p *P) {
+	if cap(s.availPs) < len(s.Ps) {
+		avail := make(chan *P, max(2*cap(s.availPs), len(s.Ps)))
+		for len(s.availPs) > 0 {
+			avail <- <-s.availPs
+		}
+		s.availPs = avail
+	}
+	s.availPs <- p
+}
+
+// takePLocked removes p from availPs, keeping the order of the rest,
+// and reports whether it was there. Caller must hold s.mu.
+func (s *Scheduler) takePLocked(p *P) bool {
+	found := false
+	for range len(s.availPs) {
+		q := <-s.availPs
+		if q == p && !found {
+			found = true
+			continue
+		}
+		s.availPs <- q
+	}
+	return found
+}
+
+// AddM creates an M and binds it to a P (by index), taking the P off
+// availPs. A negative index leaves the M without a P: it starts parked
+// and grabs one from the free Ps once running. Once Run has started the
+// Ms, the new M starts running too. AddM fails with ErrNoSuchP for an
+// index past the end of Ps, with ErrPHeld if another M holds that P,
+// and with ErrSchedulerStopped after Stop.
+func (s *Scheduler) AddM(id, pIndex int) (*M, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if pIndex >= len(s.Ps) {
+		return nil, fmt.Errorf("%w: index %d of %d", ErrNoSuchP, pIndex, len(s.Ps))
+	}
+	if s.stoppedLocked() {
+		return nil, ErrSchedulerStopped
+	}
+	if pIndex >= 0 && !s.takePLocked(s.Ps[pIndex]) {
+		return nil, fmt.Errorf("%w: P%d", ErrPHeld, s.Ps[pIndex].ID)
+	}
+
+	m := &M{
+		ID:   id,
+		stop: make(chan struct{}),
+		wake: make(chan struct{}, 1),
+	}
+	if pIndex >= 0 {
+		m.P = s.Ps[pIndex]
+	} else {
+		m.setState(MParked)
+	}
+
+	s.Ms = append(s.Ms, m)
+	if s.launched {
+		s.wg.Add(1)
+		go m.run(s)
+	}
+	return m, nil
+}
+
+// stoppedLocked reports whether Stop has been called.
+// Caller must hold s.mu.
+func (s *Scheduler) stoppedLocked() bool {
+	select {
+	case <-s.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// Enqueue adds a G to the run queue of the P chosen by the placement
+// policy, suggesting p. The default policy keeps it on p, overflowing to
+// globalQ (so idle Ms can steal it) when p is full. With GlobalQueueCap
+// set it may wait for room or fail with ErrQueueFull; see BlockOnFull.
+func (s *Scheduler) Enqueue(p *P, g *G) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g.lastP = s.liveLocked(p)
+	return s.placeLocked(g)
+}
+
+// EnqueueBatch spreads gs evenly over all Ps, each G going to the
+// least-loaded P at that point, under a single lock acquisition. Unlike
+// Enqueue it bypasses the placement policy and overflow threshold: the
+// point is to deal a large batch out evenly, and stealing rebalances
+// from there. Like Enqueue it fails with ErrSchedulerStopped after Stop,
+// and with ErrNoSuchP for a G pinned to a P that doesn't exist; either
+// way none of gs is queued.
+func (s *Scheduler) EnqueueBatch(gs []*G) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stoppedLocked() {
+		return ErrSchedulerStopped
+	}
+	pinned := make([]*P, len(gs))
+	for i, g := range gs {
+		pp, err := s.pinnedLocked(g)
+		if err != nil {
+			return err
+		}
+		pinned[i] = pp
+	}
+	now := s.now()
+	for i, g := range gs {
+		g.readyAt = now
+		if g.CreatedAt.IsZero() {
+			g.CreatedAt = now
+		}
+		g.setStatus(Runnable)
+		if pp := pinned[i]; pp != nil {
+			pp.push(g)
+			continue
+		}
+		if s.Centralized {
+			s.globalQ = append(s.globalQ, g)
+			continue
+		}
+		s.leastLoadedLocked().push(g)
+	}
+	s.wakeMs()
+	return nil
+}
+
+// Submit creates a runnable G for f and queues it on the P chosen by
+// the placement policy. With the default policy that is the calling G's
+// P when called from inside a G, otherwise the least-loaded P, with
+// globalQ taking the overflow. It is safe to call concurrently while Ms
+// are running, and fails with ErrShutdown once Shutdown has begun, or
+// ErrTooManyGs while MaxGs Gs are live. When globalQ is at
+// GlobalQueueCap it waits or fails like Enqueue.
+func (s *Scheduler) Submit(f func()) (*G, error) {
+	return s.submit(func() *G { return s.NewG(f, false) }, s.placeLocked)
+}
+
+// SubmitErr is Submit for a function that returns an error, as with
+// NewGErr. After Wait the error is in the G's Result.
+func (s *Scheduler) SubmitErr(f func() error) (*G, error) {
+	return s.submit(func() *G { return s.NewGErr(f) }, s.placeLocked)
+}
+
+// submit makes a G with newG under MaxGs and queues it with place,
+// which is called with s.mu held, for Submit, SubmitErr and
+// SubmitSystem.
+func (s *Scheduler) submit(newG func() *G, place func(*G) error) (*G, error) {
+	s.mu.Lock()
+	err := s.reserveGLocked()
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	g := newG()
+	if cur := s.current(); cur != nil && cur.lastP != s.sysP {
+		// A user G submitted from a system G must not follow it there.
+		g.lastP = cur.lastP
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reservedGs--
+	if err := place(g); err != nil {
+		// Never handed out: don't let it hold a place under MaxGs.
+		s.created--
+		return nil, err
+	}
+	return g, nil
+}
+
+// Schedules until stop
+func (m *M) run(s *Scheduler) {
+	defer s.wg.Done()
+	defer m.stopSpinning(s)
+	defer func() {
+		if r := recover(); r != nil {
+			s.printTrace()
+			panic(r)
+		}
+	}()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-s.ctx.Done():
+			return
+		default:
+		}
+		if !m.waitResumed(s) {
+			return
+		}
+
+		gen := s.spinGeneration()
+		worked := m.scheduleOnce(s)
+		if !s.EventDriven {
+			if !m.spin(s, worked, gen) {
+				return
+			}
+			if !s.AdaptiveTick {
+				time.Sleep(s.TickInterval)
+			} else if !m.sleepAdaptive(s, worked) {
+				return
+			}
+			continue
+		}
+		if worked {
+			// Busy: go straight to the next round.
+			continue
+		}
+		// Idle: sleep until someone signals new work.
+		select {
+		case <-m.stop:
+			return
+		case <-s.ctx.Done():
+			return
+		case <-m.wake:
+			// Real work arrived, so skip the anti-thrash cooldown.
+			m.parkTime = time.Time{}
+		}
+	}
+}
+
+// cooldown is how long m waits after parking before grabbing again:
+// ParkCooldown, doubled per consecutive empty grab, capped.
+func (m *M) cooldown(s *Scheduler) time.Duration {
+	d, limit := s.ParkCooldown, maxCooldownFactor*s.ParkCooldown
+	for i := 0; i < m.emptyGrabs && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
+
+// wakeMs signals every M that work may be available. Tokens are
+// buffered, so a signal sent while an M is mid-round isn't lost.
+// Caller must hold s.mu.
+func (s *Scheduler) wakeMs() {
+	for _, m := range s.Ms {
+		select {
+		case m.wake <- struct{}{}:
+		default:
+		}
+	}
+	s.signalSpinners()
+}
+
+// Runs one scheduling round: grab a P if needed, steal from global
+// if the local queue is empty, then run a G. Reports whether a G ran.
+func (m *M) scheduleOnce(s *Scheduler) bool {
+	defer m.tick()
+	if m.G != nil {
+		// Still in a "syscall" with a blocked G.
+		m.setState(MSyscall)
+		return m.exitSyscall(s)
+	}
+
+	grabbed := false
+	if m.P == nil {
+		m.setState(MParked)
+		// Cooldown: Skip grab right after park
+		if !m.parkTime.IsZero() && s.now().Sub(m.parkTime) < m.cooldown(s) {
+			s.cooldownSkips.Add(1)
+			return false
+		}
+
+		// Reset cooldown
+		m.parkTime = time.Time{}
+
+		// Non-block grab
+		s.mu.Lock()
+		select {
+		case p := <-s.availPs:
+			if p == m.lastP && len(s.availPs) > 0 {
+				// Soft affinity: prefer a P we didn't just release.
+				other := <-s.availPs
+				s.availPs <- p
+				p = other
+			}
+			m.P = p
+			m.setState(MSpinning)
+			grabbed = true
+			s.grabs++
+			s.emit(SchedEvent{Kind: EventGrab, MID: m.ID, PID: p.ID, GID: -1})
+			if onGrab := s.onGrab; onGrab != nil {
+				s.mu.Unlock()
+				onGrab(m.ID, p.ID)
+				s.mu.Lock()
+			}
+		default:
+			s.mu.Unlock()
+			return false
+		}
+	} else {
+		s.mu.Lock()
+	}
+	if m.P.retired {
+		// SetNumP removed our P: drop it and go grab another.
+		s.releasePLocked(m.P)
+		m.P = nil
+		m.setState(MParked)
+		s.mu.Unlock()
+		return false
+	}
+	if s.atRunLimitLocked() || s.resumed != nil {
+		s.mu.Unlock()
+		return false
+	}
+
+	if m.P == s.sysP {
+		// The system P runs only its own queue: it never takes Gs from
+		// globalQ or peers, and its M never hands it off.
+		if m.P.NumG == 0 {
+			s.mu.Unlock()
+			return false
+		}
+	} else if g := s.takeStarvedGlobal(); g != nil {
+		// Fairness: don't let an overflowed G starve behind a busy P.
+		s.steals++
+		s.emit(SchedEvent{Kind: EventSteal, MID: m.ID, PID: m.P.ID, GID: g.ID, From: -1, N: 1})
+		m.G = g
+		s.running++
+		s.mu.Unlock()
+		m.execute(s, g)
+		return true
+	} else if g := s.takeStarvedLocked(m); g != nil {
+		// Starved by priorities or a long queue: run it now.
+		s.promotions++
+		m.G = g
+		s.running++
+		s.mu.Unlock()
+		m.execute(s, g)
+		return true
+	}
+
+	if m.P.NumG == 0 {
+		// Local empty: Steal from global, then from the busiest peer.
+		if len(s.globalQ) > 0 {
+			g := s.takeGlobalLocked()
+			m.P.push(g)
+			s.steals++
+			s.emit(SchedEvent{Kind: EventSteal, MID: m.ID, PID: m.P.ID, GID: g.ID, From: -1, N: 1})
+		} else if victim, n := s.stealFromPeer(m.P); n > 0 {
+			s.steals++
+			first := m.P.RunQ[len(m.P.RunQ)-n].ID
+			s.emit(SchedEvent{Kind: EventSteal, MID: m.ID, PID: m.P.ID, GID: first, From: victim.ID, N: n})
+		} else {
+			s.emit(SchedEvent{Kind: EventPark, MID: m.ID, PID: m.P.ID, GID: -1})
+			s.releasePLocked(m.P)
+			s.handoffs++
+			m.lastP = m.P
+			m.P = nil
+			m.setState(MParked)
+			if grabbed {
+				m.emptyGrabs++
+			}
+			onHandoff := s.onHandoff
+			s.mu.Unlock()
+			if onHandoff != nil {
+				onHandoff(m.ID, m.lastP.ID)
+			}
+			// Start cool down
+			m.parkTime = s.now()
+			return false
+		}
+	}
+
+	// Run a G.
+	g := s.popLocked(m.P)
+	// Bind under the lock so WaitIdle never sees the G in neither place.
+	m.G = g
+	s.running++
+	s.mu.Unlock()
+
+	m.execute(s, g)
+	return true
+}
+
+// execute runs (or resumes) g, which is already bound to m, until it
+// finishes, yields or blocks.
+func (m *M) execute(s *Scheduler, g *G) {
+	p := m.P
+	kind := EventStart
+	if g.started.Load() {
+		kind = EventResume
+	} else {
+		g.StartedAt = s.now()
+	}
+	s.emit(SchedEvent{Kind: kind, MID: m.ID, PID: p.ID, GID: g.ID})
+	g.lastP = p
+	g.sliceStart = s.now()
+	m.setState(MRunning)
+	m.ran = true
+	g.setStatus(Running)
+
+	runStart := time.Now()
+	g.Run()
+	ran := int64(time.Since(runStart))
+	m.busy.Add(ran)
+	p.busy.Add(ran)
+	preempted := g.preempted
+	g.preempted = false
+	global := g.global
+	g.global = false
+	if s.Tracing {
+		end := s.now()
+		s.mu.Lock()
+		s.runSlices = append(s.runSlices, runSlice{gid: g.ID, pid: p.ID, mid: m.ID, start: g.sliceStart, end: end})
+		s.mu.Unlock()
+	}
+
+	m.emptyGrabs = 0
+
+	s.mu.Lock()
+	s.running--
+	if s.maxRunning > 0 {
+		s.wakeMs()
+	}
+	// Read once: a requeued G may be picked up by another M right away.
+	st := g.Status()
+	handedOff := false
+	if st == Waiting {
+		m.G = nil
+		commit := g.commit
+		g.commit = nil
+		if commit() {
+			s.waiting++
+		} else {
+			// Nothing to wait for after all: requeue like a yield.
+			g.setStatus(Runnable)
+			st = Runnable
+		}
+	}
+	switch st {
+	case Runnable:
+		// Yielded or preempted: back to the tail of our P's queue, or of
+		// globalQ after Gosched (or when Centralized) so any P may pick
+		// it up.
+		g.readyAt = s.now()
+		if (global || s.Centralized) && g.PinnedP < 0 {
+			s.globalQ = append(s.globalQ, g)
+			s.wakeMs()
+		} else {
+			s.liveLocked(p).push(g)
+		}
+		m.G = nil
+		if preempted {
+			s.preemptions++
+		}
+	case Blocked:
+		// Syscall: hand off the P so other work proceeds; the M stays
+		// with its G until it is unblocked. With sysmon on, the M keeps
+		// the P and sysmon retakes it if the syscall runs long.
+		s.blockedGs[g.ID] = g
+		g.blockedAt = s.now()
+		if s.SysmonThreshold <= 0 && p != s.sysP {
+			s.releasePLocked(p)
+			s.handoffs++
+			handedOff = true
+			m.lastP = p
+			m.P = nil
+			s.wakeMs()
+		}
+		if g.blockFor > 0 {
+			s.afterFunc(g.blockFor, func() { s.Unblock(g.ID) })
+		}
+		if g.wake != nil {
+			go func() {
+				select {
+				case <-g.wake:
+					s.Unblock(g.ID)
+				case <-s.stop:
+				}
+			}()
+		}
+	case Done, Failed:
+		// Already counted by finish.
+		m.G = nil
+	}
+	if st == Blocked {
+		m.setState(MSyscall)
+	} else {
+		m.setState(MSpinning)
+	}
+	onIdle := s.onIdle
+	if onIdle != nil && s.isIdleLocked() {
+		s.inIdleHook = true
+	} else {
+		onIdle = nil
+	}
+	s.idle.Broadcast()
+	onHandoff := s.onHandoff
+	s.mu.Unlock()
+	if handedOff && onHandoff != nil {
+		onHandoff(m.ID, p.ID)
+	}
+	if onIdle != nil {
+		defer func() {
+			onIdle(s)
+			s.mu.Lock()
+			s.inIdleHook = false
+			s.idle.Broadcast()
+			s.mu.Unlock()
+		}()
+	}
+
+	ev := SchedEvent{MID: m.ID, PID: p.ID, GID: g.ID}
+	switch st {
+	case Runnable:
+		ev.Kind = EventYield
+		if preempted {
+			ev.Kind = EventPreempt
+		}
+		s.emit(ev)
+	case Waiting:
+		ev.Kind = EventWait
+		s.emit(ev)
+	case Blocked:
+		ev.Kind = EventBlock
+		if s.SysmonThreshold > 0 {
+			ev.Kind = EventSyscall
+		}
+		s.emit(ev)
+	case Done:
+		ev.Kind = EventFinish
+		s.emit(ev)
+	case Failed:
+		ev.Kind = EventFail
+		s.emit(ev)
+		if perr, ok := g.Err.(*PanicError); ok && s.onPanic != nil {
+			s.onPanic(g, perr.Value)
+		}
+	}
+}
+
+// exitSyscall checks whether m's blocked G has been unblocked. If so it
+// grabs a free P and resumes the G, or moves the G to globalQ when no P
+// is free, like the runtime's exitsyscall. Reports whether the G woke.
+func (m *M) exitSyscall(s *Scheduler) bool {
+	g := m.G
+	if len(g.blockChan) == 0 {
+		return false
+	}
+	if s.ResumeJitter > 0 && !m.resumeJittered(s) {
+		return false
+	}
+	<-g.blockChan
+
+	s.mu.Lock()
+	if s.atRunLimitLocked() || s.resumed != nil {
+		// Capped or paused: keep the wakeup for a later round.
+		g.blockChan <- struct{}{}
+		s.mu.Unlock()
+		return false
+	}
+	delete(s.blockedGs, g.ID)
+	// Out of the syscall: Check expects an MSyscall M's G registered.
+	m.setState(MRunning)
+	if p := m.P; p != nil {
+		// Short syscall: sysmon never retook our P, so keep going on it.
+		s.running++
+		s.mu.Unlock()
+		s.emit(SchedEvent{Kind: EventUnblock, MID: m.ID, PID: p.ID, GID: g.ID})
+		m.execute(s, g)
+		return true
+	}
+	s.resumeGrabs++
+	select {
+	case p := <-s.availPs:
+		m.P = p
+		s.running++
+		onGrab := s.onGrab
+		s.mu.Unlock()
+		if onGrab != nil {
+			onGrab(m.ID, p.ID)
+		}
+		s.emit(SchedEvent{Kind: EventUnblock, MID: m.ID, PID: p.ID, GID: g.ID})
+		m.execute(s, g)
+	default:
+		g.setStatus(Runnable)
+		g.readyAt = s.now()
+		if pp, _ := s.pinnedLocked(g); pp != nil {
+			pp.push(g)
+		} else {
+			s.globalQ = append(s.globalQ, g)
+		}
+		m.G = nil
+		m.setState(MParked)
+		s.wakeMs()
+		s.mu.Unlock()
+		s.emit(SchedEvent{Kind: EventUnblock, MID: m.ID, PID: -1, GID: g.ID})
+	}
+	return true
+}
+
+// resumeJittered reports whether m, whose G has just been unblocked, has
+// waited out its random ResumeJitter delay and may go for a P. The
+// first call picks the delay. An M that still holds its P needn't wait.
+// It reads m.P under s.mu, as sysmon may retake the P meanwhile.
+func (m *M) resumeJittered(s *Scheduler) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m.P != nil {
+		return true
+	}
+	now := s.now()
+	if m.resumeAt.IsZero() {
+		var d int64
+		if s.rng != nil {
+			d = s.rng.Int63n(int64(s.ResumeJitter))
+		} else {
+			d = rand.Int63n(int64(s.ResumeJitter))
+		}
+		m.resumeAt = now.Add(time.Duration(d))
+	}
+	if now.Before(m.resumeAt) {
+		return false
+	}
+	m.resumeAt = time.Time{}
+	return true
+}
+
+// Unblock wakes the blocked G with the given ID. It returns
+// ErrNotBlocked if no G with that ID is currently blocked, or
+// ErrAlreadyResumed if it has already been signalled.
+func (s *Scheduler) Unblock(gid int) error {
+	s.mu.Lock()
+	g, ok := s.blockedGs[gid]
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: G%d", ErrNotBlocked, gid)
+	}
+	return g.Unblock()
+}
+
+// Unblock signals a blocking G (see NewGBlocking) to resume. Signalling
+// before the G has blocked is fine: it then passes straight through. Only
+// the first signal counts; later calls return ErrAlreadyResumed rather
+// than panicking on a closed or full channel.
+func (g *G) Unblock() error {
+	if g.blockChan == nil {
+		return fmt.Errorf("%w: G%d never blocks", ErrNotBlocked, g.ID)
+	}
+	err := ErrAlreadyResumed
+	g.unblockOnce.Do(func() {
+		select {
+		case g.blockChan <- struct{}{}:
+			err = nil
+		default:
+		}
+	})
+	if err == nil {
+		g.sched.mu.Lock()
+		g.sched.wakeMs()
+		g.sched.mu.Unlock()
+	}
+	return err
+}
+
+// takeStarvedGlobal removes and returns the longest-waiting globalQ G if
+// it has waited past GlobalWaitLimit. Caller must hold s.mu.
+func (s *Scheduler) takeStarvedGlobal() *G {
+	if s.GlobalWaitLimit <= 0 || len(s.globalQ) == 0 {
+		return nil
+	}
+	oldest := 0
+	for i, g := range s.globalQ {
+		if g.readyAt.Before(s.globalQ[oldest].readyAt) {
+			oldest = i
+		}
+	}
+	g := s.globalQ[oldest]
+	if s.now().Sub(g.readyAt) < s.GlobalWaitLimit {
+		return nil
+	}
+	s.globalQ = append(s.globalQ[:oldest], s.globalQ[oldest+1:]...)
+	s.space.Broadcast()
+	return g
+}
+
+// takeStarvedLocked removes and returns the longest-waiting runnable G
+// that m's P may run, from globalQ or any P's queue, if it has waited
+// past StarvationThreshold. A G taken from another P counts as a steal.
+// Caller must hold s.mu.
+func (s *Scheduler) takeStarvedLocked(m *M) *G {
+	if s.StarvationThreshold <= 0 {
+		return nil
+	}
+	var oldest *G
+	var from *P
+	consider := func(g *G, p *P) {
+		if g.PinnedP >= 0 && g.PinnedP != m.P.ID {
+			return
+		}
+		if oldest == nil || g.readyAt.Before(oldest.readyAt) {
+			oldest, from = g, p
+		}
+	}
+	for _, g := range s.globalQ {
+		consider(g, nil)
+	}
+	for _, p := range s.Ps {
+		for _, g := range p.RunQ {
+			consider(g, p)
+		}
+	}
+	if oldest == nil || s.now().Sub(oldest.readyAt) < s.StarvationThreshold {
+		return nil
+	}
+
+	if from == nil {
+		i := slices.Index(s.globalQ, oldest)
+		s.globalQ = slices.Delete(s.globalQ, i, i+1)
+		s.space.Broadcast()
+	} else {
+		i := slices.Index(from.RunQ, oldest)
+		from.RunQ = slices.Delete(from.RunQ, i, i+1)
+		from.NumG--
+	}
+	if from != m.P {
+		pid := -1
+		if from != nil {
+			pid = from.ID
+		}
+		s.steals++
+		s.emit(SchedEvent{Kind: EventSteal, MID: m.ID, PID: m.P.ID, GID: oldest.ID, From: pid, N: 1})
+	}
+	return oldest
+}
+
+// stealFromPeer moves half of a peer P's queue onto p, like the real
+// runtime's runqsteal, or a single G under StealOne. The victim is the
+// VictimSelector's pick among the other Ps with work. Caller must hold s.mu.
+func (s *Scheduler) stealFromPeer(p *P) (*P, int) {
+	var candidates []*P
+	for _, peer := range s.Ps {
+		if peer != p && unpinnedLen(peer) > 0 {
+			candidates = append(candidates, peer)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, 0
+	}
+	sel := s.VictimSelector
+	if sel == nil {
+		sel = MostLoaded{}
+		if s.rng != nil {
+			sel = RandomVictim{Rand: s.rng}
+		}
+	}
+	victim := sel.Pick(p, candidates)
+	if victim == nil {
+		return nil, 0
+	}
+
+	// Take the older half (rounded up) of the victim's unpinned Gs,
+	// from the head of its queue.
+	n := unpinnedLen(victim)
+	n -= n / 2
+	if s.StealPolicy == StealOne {
+		n = 1
+	}
+	p.RunQ = append(p.RunQ, s.takeStolenLocked(victim, n)...)
+	p.NumG += n
+	return victim, n
+}
+
+// WaitIdle blocks until globalQ and every P's queue are empty and no M
+// is running a G, or until ctx is done (returning ctx.Err()).
+func (s *Scheduler) WaitIdle(ctx context.Context) error {
+	// Wake the waiter below if ctx ends first.
+	stop := context.AfterFunc(ctx, func() {
+		s.mu.Lock()
+		s.idle.Broadcast()
+		s.mu.Unlock()
+	})
+	defer stop()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for !s.isIdleLocked() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		s.idle.Wait()
+	}
+	return nil
+}
+
+// Caller must hold s.mu.
+func (s *Scheduler) isIdleLocked() bool {
+	if len(s.globalQ) > 0 {
+		return false
+	}
+	for _, p := range s.queuesLocked() {
+		if p.NumG > 0 {
+			return false
+		}
+	}
+	for _, m := range s.Ms {
+		if m.G != nil {
+			return false
+		}
+	}
+	return s.waiting == 0 && !s.inIdleHook
+}
+
+// Run starts every M's scheduling loop in its own goroutine. The Ms
+// start only once: calling Run, RunAll or RunContext on a scheduler
+// that is already running leaves the running Ms, and their context, as
+// they are.
+func (s *Scheduler) Run() {
+	s.start(context.Background())
+}
+
+// RunAll starts the Ms, waits until every queued G has run to the end
+// (see WaitIdle), then stops the Ms. It suits finite, non-blocking work:
+// there is no hand-picked number of rounds, so no G is left unrun.
+func (s *Scheduler) RunAll() {
+	s.Run()
+	s.WaitIdle(context.Background())
+	s.Stop()
+}
+
+// RunContext starts the Ms and blocks until they have all returned,
+// either because ctx was cancelled or Stop was called. On cancellation
+// Ms stop dequeuing new Gs, running NewGCtx Gs see ctx.Done(), and
+// RunContext returns ctx.Err(). Either way the scheduler is stopped on
+// return, as by Stop: Submit fails with ErrSchedulerStopped and no
+// background goroutine is left behind.
+func (s *Scheduler) RunContext(ctx context.Context) error {
+	s.start(ctx)
+	s.wg.Wait()
+	s.Stop()
+	return ctx.Err()
+}
+
+func (s *Scheduler) start(ctx context.Context) {
+	if s.deterministic {
+		panic("toysched: Run on a scheduler made WithDeterministic; use Step")
+	}
+	s.mu.Lock()
+	if s.launched {
+		// Already running: a second goroutine per M would race the first.
+		s.mu.Unlock()
+		return
+	}
+	s.ctx = ctx
+	s.startedAt = time.Now()
+	s.launched = true
+	ms := s.Ms
+	for range ms {
+		s.wg.Add(1)
+	}
+	s.mu.Unlock()
+	context.AfterFunc(ctx, s.signalSpinners)
+	s.printf("=== Starting Toy Schedule ===\n")
+	for _, m := range ms {
+		go m.run(s)
+	}
+	if s.SysmonThreshold > 0 {
+		s.wg.Add(1)
+		go s.startSysmon()
+	}
+	s.mu.Lock()
+	if !s.watchdog && (s.DeadlockTicks > 0 || s.onDeadlock != nil || s.allParked != nil) {
+		s.watchdog = true
+		s.wg.Add(1)
+		go s.startWatchdog()
+	}
+	s.mu.Unlock()
+}
+
+// Stop closes every M's stop channel (once) and waits until all
+// M goroutines have returned. Calling Stop again is a no-op. Close does
+// the same and also reports Gs left parked.
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	for _, m := range s.Ms {
+		m.stopOnce.Do(func() { close(m.stop) })
+	}
+	s.stopOnce.Do(func() { close(s.stop) })
+	// Release anyone waiting for queue space.
+	s.space.Broadcast()
+	s.mu.Unlock()
+	s.signalSpinners()
+	s.wg.Wait()
+}
@@ -0,0 +1,781 @@
+package toysched
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAddPBeforeRunIsUsable(t *testing.T) {
+	s, _ := NewScheduler(1, 1)
+	s.SetConsole(nil)
+	p := s.AddP(1)
+	if err := s.Check(); err != nil {
+		t.Fatal(err)
+	}
+	m, err := s.AddM(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.P != p {
+		t.Fatalf("new M holds %v, want the added P", m.P)
+	}
+	if err := s.Check(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAddMOwnership(t *testing.T) {
+	tests := []struct {
+		name          string
+		numP, numM    int
+		pIndex        int
+		wantErr       error
+		wantHeldByNew bool
+	}{
+		{"free P", 2, 1, 1, nil, true},
+		{"held P", 2, 1, 0, ErrPHeld, false},
+		{"no P", 2, 2, -1, nil, false},
+		{"past the end", 2, 1, 2, ErrNoSuchP, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := NewScheduler(tt.numP, tt.numM)
+			s.SetConsole(nil)
+			m, err := s.AddM(9, tt.pIndex)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddM: err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (m.P != nil) != tt.wantHeldByNew {
+				t.Fatalf("new M holds %v", m.P)
+			}
+			if err := s.Check(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestAddMAfterRun(t *testing.T) {
+	s, _ := NewScheduler(2, 1)
+	s.SetConsole(nil)
+	s.EventDriven = true
+	s.Run()
+	defer s.Stop()
+	if _, err := s.AddM(1, -1); err != nil {
+		t.Fatal(err)
+	}
+	var gs []*G
+	for range 10 {
+		g, err := s.Submit(func() {})
+		if err != nil {
+			t.Fatal(err)
+		}
+		gs = append(gs, g)
+	}
+	if err := s.WaitIdle(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range gs {
+		if g.Status() != Done {
+			t.Fatalf("G%d is %v", g.ID, g.Status())
+		}
+	}
+	if err := s.Check(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAddPAndMWhileRunning(t *testing.T) {
+	s, _ := NewScheduler(1, 1, WithLogger(nil))
+	s.TickInterval = time.Millisecond
+	s.ParkCooldown = time.Millisecond
+	s.Run()
+	defer s.Stop()
+
+	const added, n = 3, 200
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := range added {
+			s.AddP(1 + i)
+			if _, err := s.AddM(1+i, -1); err != nil {
+				t.Error(err)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	var gs []*G
+	go func() {
+		defer wg.Done()
+		for range n {
+			g, err := s.Submit(func() {})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			gs = append(gs, g)
+		}
+	}()
+	go func() {
+		// Readers that walk Ps and Ms while they grow.
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			s.Stats()
+			s.State()
+			s.QueuedGs(1)
+			time.Sleep(100 * time.Microsecond)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for len(s.State().Ms) < 1+added {
+		time.Sleep(time.Millisecond)
+	}
+	close(done)
+	wg.Wait()
+	if err := s.WaitIdle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range gs {
+		if g.Status() != Done {
+			t.Fatalf("G%d is %v", g.ID, g.Status())
+		}
+	}
+	if st := s.State(); len(st.Ps) != 1+added || len(st.Ms) != 1+added {
+		t.Fatalf("%d Ps and %d Ms, want %d of each", len(st.Ps), len(st.Ms), 1+added)
+	}
+	if err := s.Check(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSubmitConcurrentWhileRunning(t *testing.T) {
+	s, _ := NewScheduler(4, 4, WithLogger(nil))
+	s.TickInterval = time.Millisecond
+	s.Run()
+	defer s.Stop()
+
+	var mu sync.Mutex
+	var gs []*G
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				g, err := s.Submit(func() {})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				gs = append(gs, g)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if err := s.WaitIdle(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(gs) != 800 {
+		t.Fatalf("submitted %d Gs, want 800", len(gs))
+	}
+	for _, g := range gs {
+		if g.Status() != Done {
+			t.Fatalf("G%d is %v", g.ID, g.Status())
+		}
+	}
+	if st := s.Stats(); st.Completed != 800 {
+		t.Fatalf("Completed = %d, want 800", st.Completed)
+	}
+}
+
+func TestSubmitPlacesOnLeastLoadedP(t *testing.T) {
+	tests := []struct {
+		name   string
+		queued []int
+		wantP  int // -1 for globalQ
+	}{
+		{"empty Ps", []int{0, 0, 0}, 0},
+		{"shortest queue", []int{3, 1, 2}, 1},
+		{"all full", []int{6, 6, 6}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, len(tt.queued), len(tt.queued))
+			for i, n := range tt.queued {
+				for range n {
+					s.Ps[i].push(s.NewG(func() {}, false))
+				}
+			}
+			g, err := s.Submit(func() {})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantP < 0 {
+				if q := s.GlobalQueued(); len(q) != 1 || q[0] != g.ID {
+					t.Fatalf("globalQ = %v, want [%d]", q, g.ID)
+				}
+				return
+			}
+			q := s.Ps[tt.wantP].RunQ
+			if q[len(q)-1] != g {
+				t.Fatalf("G%d not at the tail of P%d", g.ID, tt.wantP)
+			}
+		})
+	}
+}
+
+func TestPopHighestPriorityFIFO(t *testing.T) {
+	tests := []struct {
+		name  string
+		prios []int
+		want  []int // indexes into prios, in pop order
+	}{
+		{"all equal", []int{0, 0, 0}, []int{0, 1, 2}},
+		{"higher first", []int{0, 5, 1}, []int{1, 2, 0}},
+		{"FIFO within level", []int{1, 2, 1, 2}, []int{1, 3, 0, 2}},
+		{"negative last", []int{-1, 0, -1}, []int{1, 0, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, 1, 1)
+			var gs []*G
+			for _, prio := range tt.prios {
+				g := s.NewGWithPriority(func() {}, prio)
+				s.Ps[0].push(g)
+				gs = append(gs, g)
+			}
+			for _, i := range tt.want {
+				if g := s.Ps[0].pop(); g != gs[i] {
+					t.Fatalf("popped G%d, want G%d", g.ID, gs[i].ID)
+				}
+			}
+			if g := s.Ps[0].pop(); g != nil || s.Ps[0].NumG != 0 {
+				t.Fatalf("queue not drained: popped %v, NumG %d", g, s.Ps[0].NumG)
+			}
+		})
+	}
+}
+
+func TestHighPriorityRunsBeforeLongLowPriority(t *testing.T) {
+	s := newDeterministic(t, 1, 1)
+	var order []string
+	s.Enqueue(s.Ps[0], s.NewGWithPriority(func() {
+		order = append(order, "low start")
+		for range 5 {
+			s.Yield()
+		}
+		order = append(order, "low end")
+	}, 0))
+	for i := range 3 {
+		s.Enqueue(s.Ps[0], s.NewGWithPriority(func() {
+			order = append(order, fmt.Sprint("high ", i))
+		}, 1))
+	}
+	s.RunDeterministic()
+
+	want := []string{"high 0", "high 1", "high 2", "low start", "low end"}
+	if !slices.Equal(order, want) {
+		t.Fatalf("order = %q, want %q", order, want)
+	}
+}
+
+func TestStarvationThresholdPromotesOldG(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int // in ticks; 0 disables promotion
+	}{
+		{"disabled", 0},
+		{"three ticks", 3},
+		{"ten ticks", 10},
+	}
+	const stream = 100
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, 1, 1)
+			s.StarvationThreshold = time.Duration(tt.threshold) * s.TickInterval
+			low := s.NewGWithPriority(func() {}, 0)
+			s.Enqueue(s.Ps[0], low)
+
+			// A never-empty stream of high-priority Gs, each queueing the next.
+			fed := 0
+			var feed func()
+			feed = func() {
+				if fed++; fed < stream {
+					s.Enqueue(s.Ps[0], s.NewGWithPriority(feed, 5))
+				}
+			}
+			s.Enqueue(s.Ps[0], s.NewGWithPriority(feed, 5))
+
+			ticks := 0
+			for low.Status() != Done && s.Step() {
+				ticks++
+			}
+			if tt.threshold == 0 {
+				if fed != stream {
+					t.Fatalf("low-priority G ran after %d of %d stream Gs with no threshold", fed, stream)
+				}
+				return
+			}
+			// Waits out the threshold, then runs on the next tick or two.
+			if margin := tt.threshold + 2; ticks < tt.threshold || ticks > margin {
+				t.Fatalf("low-priority G ran after %d ticks, want %d-%d", ticks, tt.threshold, margin)
+			}
+			if st := s.Stats(); st.Promotions != 1 {
+				t.Fatalf("Promotions = %d, want 1", st.Promotions)
+			}
+		})
+	}
+}
+
+func TestGlobalWaitLimitRescuesOverflowedG(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     int // in ticks; 0 disables the guard
+		wantSteps int // the overflowed G is done within this many Steps
+	}{
+		{"guard off", 0, 0},
+		{"guard at 3 ticks", 3, 5},
+		{"guard at 10 ticks", 10, 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, 1, 1)
+			s.GlobalWaitLimit = time.Duration(tt.limit) * s.TickInterval
+			busy := func() {
+				for range 20 {
+					s.Yield()
+				}
+			}
+			for range overflowThreshold + 1 {
+				s.Enqueue(s.Ps[0], s.NewG(busy, false))
+			}
+			late := s.NewG(func() {}, false)
+			s.Enqueue(s.Ps[0], late)
+			if q := s.GlobalQueued(); len(q) != 1 || q[0] != late.ID {
+				t.Fatalf("globalQ = %v, want the overflowed G%d", q, late.ID)
+			}
+
+			steps := 0
+			for late.Status() != Done && s.Step() {
+				steps++
+			}
+			if late.Status() != Done {
+				t.Fatalf("overflowed G is %v", late.Status())
+			}
+			if tt.wantSteps == 0 {
+				// Unguarded, it waits until the local queue drains.
+				if st := s.Stats(); st.Completed != overflowThreshold+2 {
+					t.Fatalf("overflowed G ran with %d Gs done", st.Completed)
+				}
+				return
+			}
+			if steps > tt.wantSteps {
+				t.Fatalf("overflowed G done after %d Steps, want at most %d", steps, tt.wantSteps)
+			}
+		})
+	}
+}
+
+func TestEnqueueBatchSpreadsEvenly(t *testing.T) {
+	tests := []struct {
+		name   string
+		numP   int
+		n      int
+		pinned int // Gs pinned to P0, which go there regardless
+	}{
+		{"1000 over 3", 3, 1000, 0},
+		{"1000 over 4", 4, 1000, 0},
+		{"fewer Gs than Ps", 5, 3, 0},
+		{"pinned Gs stay put", 2, 10, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, tt.numP, tt.numP)
+			gs := make([]*G, tt.n)
+			for i := range gs {
+				gs[i] = s.NewG(func() {}, false)
+			}
+			for i := range tt.pinned {
+				gs[i].PinnedP = 0
+			}
+			if err := s.EnqueueBatch(gs); err != nil {
+				t.Fatal(err)
+			}
+
+			lo, hi, total := tt.n, 0, 0
+			for _, p := range s.Ps {
+				lo, hi = min(lo, p.NumG), max(hi, p.NumG)
+				total += p.NumG
+			}
+			if total != tt.n {
+				t.Fatalf("%d Gs queued, want %d", total, tt.n)
+			}
+			if tt.pinned == 0 && hi-lo > 1 {
+				t.Fatalf("queue depths range from %d to %d", lo, hi)
+			}
+			if s.Ps[0].NumG < tt.pinned {
+				t.Fatalf("P0 holds %d Gs, fewer than the %d pinned to it", s.Ps[0].NumG, tt.pinned)
+			}
+			s.RunDeterministic()
+			if st := s.Stats(); st.Completed != tt.n {
+				t.Fatalf("Completed = %d, want %d", st.Completed, tt.n)
+			}
+		})
+	}
+}
+
+func TestMoreMsThanPs(t *testing.T) {
+	s, _ := NewScheduler(2, 4, WithLogger(nil))
+	s.TickInterval = time.Millisecond
+	s.ParkCooldown = time.Millisecond
+	for i, m := range s.Ms {
+		if want := i >= 2; (m.State() == MParked) != want {
+			t.Fatalf("M%d starts %v", m.ID, m.State())
+		}
+	}
+
+	if st := s.Stats(); st.ParkedMs != 2 {
+		t.Fatalf("ParkedMs = %d before Run, want 2", st.ParkedMs)
+	}
+
+	var running, most atomic.Int32
+	for range 40 {
+		s.Submit(func() {
+			n := running.Add(1)
+			for {
+				old := most.Load()
+				if n <= old || most.CompareAndSwap(old, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+		})
+	}
+	s.RunAll()
+
+	if st := s.Stats(); st.Completed != 40 {
+		t.Fatalf("Completed = %d, want 40", st.Completed)
+	}
+	if got := most.Load(); got != 2 {
+		t.Fatalf("at most %d Gs ran at once, want 2", got)
+	}
+	if err := s.Check(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunAllAfterRun(t *testing.T) {
+	s, _ := NewScheduler(2, 2, WithLogger(nil))
+	s.TickInterval = time.Millisecond
+	var runs [20]atomic.Int32
+	for i := range runs {
+		s.Submit(func() { runs[i].Add(1) })
+	}
+	s.Run()
+	s.Run() // already running: no second set of Ms
+	s.RunAll()
+
+	for i := range runs {
+		if n := runs[i].Load(); n != 1 {
+			t.Fatalf("G %d ran %d times, want once", i, n)
+		}
+	}
+	if st := s.Stats(); st.Completed != len(runs) {
+		t.Fatalf("Completed = %d, want %d", st.Completed, len(runs))
+	}
+}
+
+func TestSurplusMTakesHandedOffP(t *testing.T) {
+	s := newDeterministic(t, 1, 2)
+	blocked := s.NewGBlocking(func() {}, 0)
+	other := s.NewG(func() {}, false)
+	s.Enqueue(s.Ps[0], blocked)
+	s.Enqueue(s.Ps[0], other)
+
+	s.Step() // M0 runs the G, which blocks; M0 hands off P0
+	s.Step() // M1 grabs P0 and runs the other G
+	if other.Status() != Done {
+		t.Fatalf("other G is %v, want Done", other.Status())
+	}
+	if mid, ok := s.RunningOn(other.ID); ok {
+		t.Fatalf("other G still on M%d", mid)
+	}
+	if s.Ms[1].P != s.Ps[0] || s.Ms[0].P != nil {
+		t.Fatalf("P0 held by M0: %v, M1: %v", s.Ms[0].P != nil, s.Ms[1].P != nil)
+	}
+	blocked.Unblock()
+	s.RunDeterministic()
+	if blocked.Status() != Done {
+		t.Fatalf("blocked G is %v", blocked.Status())
+	}
+}
+
+func TestHandoffAndGrabHooks(t *testing.T) {
+	type call struct{ kind, m, p int }
+	const handoff, grab = 0, 1
+	tests := []struct {
+		name string
+		numM int
+		gs   func(s *Scheduler) []*G
+		want []call
+	}{
+		{"idle M parks", 1, func(*Scheduler) []*G { return nil },
+			[]call{{handoff, 0, 0}}},
+		{"blocked G hands off to a surplus M", 2, func(s *Scheduler) []*G {
+			return []*G{s.NewGBlocking(func() {}, s.TickInterval), s.NewG(func() {}, false)}
+		}, []call{{handoff, 0, 0}, {grab, 1, 0}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, 1, tt.numM)
+			var got []call
+			// The hooks call back into the scheduler, which would
+			// deadlock if they ran with the lock held.
+			s.OnHandoff(func(m, p int) {
+				s.Stats()
+				got = append(got, call{handoff, m, p})
+			})
+			s.OnGrab(func(m, p int) {
+				s.Stats()
+				got = append(got, call{grab, m, p})
+			})
+			for _, g := range tt.gs(s) {
+				s.Enqueue(s.Ps[0], g)
+			}
+			for range len(tt.want) {
+				s.Step()
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("hook calls = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOnPanicHook(t *testing.T) {
+	s := newDeterministic(t, 1, 1)
+	type call struct {
+		g *G
+		v any
+	}
+	var got []call
+	// Like the other hooks it runs without the lock held.
+	s.OnPanic(func(g *G, v any) {
+		s.Stats()
+		got = append(got, call{g, v})
+	})
+	bad := s.NewG(func() { panic("boom") }, false)
+	s.Enqueue(s.Ps[0], bad)
+	var after []*G
+	for range 2 {
+		g := s.NewG(func() {}, false)
+		s.Enqueue(s.Ps[0], g)
+		after = append(after, g)
+	}
+	s.RunDeterministic()
+
+	if len(got) != 1 || got[0].g != bad || got[0].v != "boom" {
+		t.Fatalf("OnPanic calls = %+v, want one for G%d with \"boom\"", got, bad.ID)
+	}
+	var perr *PanicError
+	if bad.Status() != Failed || !errors.As(bad.Err, &perr) {
+		t.Fatalf("panicking G: status %v, Err %v", bad.Status(), bad.Err)
+	}
+	// The one M carried on with the rest of the queue.
+	for _, g := range after {
+		if g.Status() != Done {
+			t.Errorf("G%d is %v after the panic, want Done", g.ID, g.Status())
+		}
+	}
+	if st := s.Stats(); st.Completed != 2 || st.Failed != 1 {
+		t.Fatalf("Completed = %d, Failed = %d; want 2, 1", st.Completed, st.Failed)
+	}
+}
+
+func TestNewGWaitingResumesOnCallerChannel(t *testing.T) {
+	tests := []struct {
+		name   string
+		signal func(chan struct{})
+	}{
+		{"close", func(c chan struct{}) { close(c) }},
+		{"send", func(c chan struct{}) { c <- struct{}{} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := NewScheduler(1, 2, WithLogger(nil))
+			s.TickInterval = time.Millisecond
+			s.ParkCooldown = time.Millisecond
+			wake := make(chan struct{})
+			g := s.NewGWaiting(func() {}, wake)
+			other := s.NewG(func() {}, false)
+			s.Enqueue(s.Ps[0], g)
+			s.Enqueue(s.Ps[0], other)
+			s.Run()
+			defer s.Stop()
+
+			time.Sleep(100 * time.Millisecond)
+			if g.Status() != Blocked {
+				t.Fatalf("G is %v before wake fired, want Blocked", g.Status())
+			}
+			if other.Status() != Done {
+				t.Fatalf("other G is %v; the P was not handed off", other.Status())
+			}
+			tt.signal(wake)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := g.WaitContext(ctx); err != nil {
+				t.Fatalf("G did not resume: %v", err)
+			}
+			if g.Status() != Done {
+				t.Fatalf("G is %v, want Done", g.Status())
+			}
+		})
+	}
+}
+
+func TestUnblockErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		unblock func(s *Scheduler, g *G) error
+		want    error
+	}{
+		{"first signal", func(s *Scheduler, g *G) error { return s.Unblock(g.ID) }, nil},
+		{"second signal", func(s *Scheduler, g *G) error {
+			g.Unblock()
+			return g.Unblock()
+		}, ErrAlreadyResumed},
+		{"second signal by ID", func(s *Scheduler, g *G) error {
+			s.Unblock(g.ID)
+			return s.Unblock(g.ID)
+		}, ErrAlreadyResumed},
+		{"unknown ID", func(s *Scheduler, g *G) error { return s.Unblock(g.ID + 100) }, ErrNotBlocked},
+		{"never blocks", func(s *Scheduler, g *G) error {
+			return s.NewG(func() {}, false).Unblock()
+		}, ErrNotBlocked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, 1, 2)
+			g := s.NewGBlocking(func() {}, 0)
+			s.Enqueue(s.Ps[0], g)
+			s.Step() // g blocks
+			if g.Status() != Blocked {
+				t.Fatalf("G is %v, want Blocked", g.Status())
+			}
+			if err := tt.unblock(s, g); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if tt.want == ErrNotBlocked {
+				g.Unblock()
+			}
+			s.RunDeterministic()
+			if g.Status() != Done {
+				t.Fatalf("G is %v, want Done", g.Status())
+			}
+			if err := g.Unblock(); !errors.Is(err, ErrAlreadyResumed) {
+				t.Fatalf("Unblock after the G finished: err = %v", err)
+			}
+		})
+	}
+}
+
+func TestOnDoneFiresOnceInOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		newG func(s *Scheduler) *G
+	}{
+		{"plain", func(s *Scheduler) *G { return s.NewG(func() {}, false) }},
+		{"blocks and resumes", func(s *Scheduler) *G { return s.NewGBlocking(func() {}, 2*s.TickInterval) }},
+		{"yields", func(s *Scheduler) *G { return s.NewG(func() { s.Yield(); s.Yield() }, false) }},
+		{"panics", func(s *Scheduler) *G { return s.NewG(func() { panic("boom") }, false) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, 1, 2)
+			g := tt.newG(s)
+			var calls []string
+			var followUp *G
+			g.OnDone(func(done *G) {
+				if done != g {
+					t.Errorf("callback got G%d, want G%d", done.ID, g.ID)
+				}
+				calls = append(calls, "first:"+done.Status().String())
+			})
+			g.OnDone(func(*G) {
+				calls = append(calls, "second")
+				// Callbacks may call back into the scheduler.
+				followUp, _ = s.Submit(func() {})
+			})
+			s.Enqueue(s.Ps[0], g)
+			s.RunDeterministic()
+
+			want := []string{"first:" + g.Status().String(), "second"}
+			if !slices.Equal(calls, want) {
+				t.Fatalf("callbacks = %q, want %q", calls, want)
+			}
+			if followUp == nil || followUp.Status() != Done {
+				t.Fatal("G submitted from OnDone did not run")
+			}
+
+			// Registering after the G finished runs the callback at once.
+			late := 0
+			g.OnDone(func(*G) { late++ })
+			if late != 1 || len(calls) != 2 {
+				t.Fatalf("late callback ran %d times, earlier ones re-ran: %q", late, calls)
+			}
+		})
+	}
+}
+
+func TestOnIdleSubmitsOneMoreBatch(t *testing.T) {
+	for _, deterministic := range []bool{true, false} {
+		var s *Scheduler
+		if deterministic {
+			s = newDeterministic(t, 2, 2)
+		} else {
+			s, _ = NewScheduler(2, 2, WithLogger(nil))
+			s.TickInterval = time.Millisecond
+		}
+		var idles atomic.Int32
+		s.OnIdle(func(s *Scheduler) {
+			if idles.Add(1) == 1 {
+				for range 5 {
+					s.Submit(func() {})
+				}
+			}
+		})
+		for range 5 {
+			s.Submit(func() {})
+		}
+		if deterministic {
+			s.RunDeterministic()
+		} else {
+			s.RunAll()
+		}
+
+		if got := s.Stats().Completed; got != 10 {
+			t.Errorf("deterministic %v: Completed = %d, want both batches", deterministic, got)
+		}
+		if got := idles.Load(); got != 2 {
+			t.Errorf("deterministic %v: OnIdle fired %d times, want once per batch", deterministic, got)
+		}
+	}
+}
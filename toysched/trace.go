@@ -0,0 +1,69 @@
+package toysched
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// One stretch of a G running on a P, recorded when Tracing is on.
+type runSlice struct {
+	gid, pid, mid int
+	start, end    time.Time
+}
+
+// traceEvent is one entry in the Chrome Trace Event Format.
+type traceEvent struct {
+	Name string         `json:"name"`
+	Ph   string         `json:"ph"`
+	Ts   float64        `json:"ts"` // microseconds
+	Pid  int            `json:"pid"`
+	Tid  int            `json:"tid"`
+	Args map[string]any `json:"args,omitempty"`
+}
+
+// WriteTrace writes every G run recorded so far (Tracing must have been
+// on) as a Chrome Trace Event Format JSON array: each P is a thread and
+// each stretch a G ran is a begin/end pair, so a G that yielded shows up
+// once per run. Load the file in chrome://tracing or Perfetto to see how
+// Gs interleaved across Ps.
+func (s *Scheduler) WriteTrace(w io.Writer) error {
+	s.mu.Lock()
+	slices := make([]runSlice, len(s.runSlices))
+	copy(slices, s.runSlices)
+	numP := len(s.Ps)
+	s.mu.Unlock()
+
+	sort.SliceStable(slices, func(i, j int) bool { return slices[i].start.Before(slices[j].start) })
+	var origin time.Time
+	if len(slices) > 0 {
+		origin = slices[0].start
+	}
+	us := func(t time.Time) float64 { return float64(t.Sub(origin).Nanoseconds()) / 1e3 }
+
+	events := make([]traceEvent, 0, numP+2*len(slices))
+	seen := make(map[int]bool)
+	for i := 0; i < numP; i++ {
+		seen[i] = true
+		events = append(events, threadName(i))
+	}
+	for _, sl := range slices {
+		if !seen[sl.pid] {
+			// A P since removed by SetNumP.
+			seen[sl.pid] = true
+			events = append(events, threadName(sl.pid))
+		}
+		name := fmt.Sprintf("G%d", sl.gid)
+		events = append(events,
+			traceEvent{Name: name, Ph: "B", Ts: us(sl.start), Tid: sl.pid, Args: map[string]any{"m": sl.mid}},
+			traceEvent{Name: name, Ph: "E", Ts: us(sl.end), Tid: sl.pid},
+		)
+	}
+	return json.NewEncoder(w).Encode(events)
+}
+
+func threadName(pid int) traceEvent {
+	return traceEvent{Name: "thread_name", Ph: "M", Tid: pid, Args: map[string]any{"name": fmt.Sprintf("P%d", pid)}}
+}
@@ -0,0 +1,80 @@
+package toysched
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteTraceMatchedEvents(t *testing.T) {
+	tests := []struct {
+		name       string
+		numP       int
+		yields     int // per G
+		wantSlices int
+	}{
+		{"one P, no yields", 1, 0, 4},
+		{"two Ps, yielding Gs", 2, 2, 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDeterministic(t, tt.numP, tt.numP)
+			s.Tracing = true
+			for i := range 4 {
+				s.Enqueue(s.Ps[i%tt.numP], s.NewG(func() {
+					for range tt.yields {
+						s.Yield()
+					}
+				}, false))
+			}
+			s.RunDeterministic()
+
+			var buf bytes.Buffer
+			if err := s.WriteTrace(&buf); err != nil {
+				t.Fatal(err)
+			}
+			var events []struct {
+				Name string  `json:"name"`
+				Ph   string  `json:"ph"`
+				Ts   float64 `json:"ts"`
+				Tid  int     `json:"tid"`
+			}
+			if err := json.Unmarshal(buf.Bytes(), &events); err != nil {
+				t.Fatalf("trace is not a JSON array: %v\n%s", err, buf.Bytes())
+			}
+
+			open := make(map[int]string) // per P thread
+			last := make(map[int]float64)
+			threads, slices := 0, 0
+			for _, e := range events {
+				switch e.Ph {
+				case "M":
+					threads++
+				case "B":
+					if prev, ok := open[e.Tid]; ok {
+						t.Fatalf("%s began on P%d while %s was running", e.Name, e.Tid, prev)
+					}
+					if e.Ts < last[e.Tid] {
+						t.Fatalf("%s began on P%d before the previous run ended", e.Name, e.Tid)
+					}
+					open[e.Tid] = e.Name
+				case "E":
+					if open[e.Tid] != e.Name {
+						t.Fatalf("%s ended on P%d without a matching begin", e.Name, e.Tid)
+					}
+					delete(open, e.Tid)
+					last[e.Tid] = e.Ts
+					slices++
+				default:
+					t.Fatalf("unexpected phase %q", e.Ph)
+				}
+			}
+			if len(open) != 0 {
+				t.Fatalf("runs never ended: %v", open)
+			}
+			if threads != tt.numP || slices != tt.wantSlices {
+				t.Fatalf("%d threads and %d runs, want %d and %d", threads, slices, tt.numP, tt.wantSlices)
+			}
+		})
+	}
+}
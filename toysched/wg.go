@@ -0,0 +1,56 @@
+package toysched
+
+// SchedWG is a sync.WaitGroup modelled inside the scheduler. A G waiting
+// on it is parked, like one blocked on a SchedChan, so its M and P move
+// on to other work instead of an OS thread sitting blocked; once the
+// counter drops to zero every waiter is requeued.
+type SchedWG struct {
+	s *Scheduler
+	// Counter and parked waiters. Guarded by s.mu.
+	n       int
+	waiters []*G
+}
+
+// NewWaitGroup creates a SchedWG for Gs of this scheduler.
+func (s *Scheduler) NewWaitGroup() *SchedWG {
+	return &SchedWG{s: s}
+}
+
+// Add adds delta, which may be negative, to the counter, waking every
+// waiting G when it reaches zero. It panics if the counter goes
+// negative. It may be called from inside or outside a G.
+func (wg *SchedWG) Add(delta int) {
+	s := wg.s
+	s.mu.Lock()
+	wg.n += delta
+	if wg.n < 0 {
+		s.mu.Unlock()
+		panic("toysched: negative SchedWG counter")
+	}
+	if wg.n == 0 {
+		for _, g := range wg.waiters {
+			s.readyLocked(g)
+		}
+		wg.waiters = nil
+	}
+	s.mu.Unlock()
+}
+
+// Done decrements the counter by one.
+func (wg *SchedWG) Done() {
+	wg.Add(-1)
+}
+
+// WaitG parks the calling G until the counter is zero, returning at once
+// if it already is. It must be called from inside a running G.
+func (wg *SchedWG) WaitG() {
+	s := wg.s
+	g := s.mustCurrent("WaitG")
+	s.gopark(g, func() bool {
+		if wg.n == 0 {
+			return false
+		}
+		wg.waiters = append(wg.waiters, g)
+		return true
+	})
+}
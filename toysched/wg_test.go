@@ -0,0 +1,105 @@
+package toysched
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSchedWGParentWaitsForChildren(t *testing.T) {
+	tests := []struct {
+		name          string
+		numP, numM    int
+		yields        []int // per child
+		deterministic bool
+	}{
+		{"one P, stepped", 1, 1, []int{0, 1, 2}, true},
+		{"three Ps, stepped", 3, 3, []int{3, 0, 1}, true},
+		{"running", 2, 2, []int{5, 5, 5}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s *Scheduler
+			if tt.deterministic {
+				s = newDeterministic(t, tt.numP, tt.numM)
+			} else {
+				s, _ = NewScheduler(tt.numP, tt.numM, WithLogger(nil))
+				s.TickInterval = time.Millisecond
+				s.ParkCooldown = time.Millisecond
+			}
+			wg := s.NewWaitGroup()
+			var children []*G
+			var ran atomic.Int32
+			seen := -1 // children finished when the parent resumed
+			parent := s.NewG(func() {
+				wg.Add(len(tt.yields))
+				for _, n := range tt.yields {
+					g, err := s.Submit(func() {
+						for range n {
+							s.Yield()
+						}
+						ran.Add(1)
+						wg.Done()
+					})
+					if err != nil {
+						t.Error(err)
+						wg.Done()
+						continue
+					}
+					children = append(children, g)
+				}
+				wg.WaitG()
+				seen = int(ran.Load())
+				if tt.deterministic {
+					// A stepped child is Done within the step that called wg.Done.
+					for _, c := range children {
+						if c.Status() != Done {
+							t.Errorf("parent resumed with child G%d %v", c.ID, c.Status())
+						}
+					}
+				}
+			}, false)
+			s.Enqueue(s.Ps[0], parent)
+
+			if tt.deterministic {
+				sawWaiting := false
+				for s.Step() {
+					sawWaiting = sawWaiting || parent.Status() == Waiting
+				}
+				if !sawWaiting {
+					t.Error("parent never parked in WaitG")
+				}
+			} else {
+				s.Run()
+				defer s.Stop()
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				if err := parent.WaitContext(ctx); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if parent.Status() != Done || seen != len(tt.yields) {
+				t.Fatalf("parent %v, resumed after %d of %d children", parent.Status(), seen, len(tt.yields))
+			}
+		})
+	}
+}
+
+func TestSchedWGZeroAndNegative(t *testing.T) {
+	s := newDeterministic(t, 1, 1)
+	wg := s.NewWaitGroup()
+	g := s.NewG(wg.WaitG, false)
+	s.Enqueue(s.Ps[0], g)
+	s.RunDeterministic()
+	if g.Status() != Done {
+		t.Fatalf("WaitG at zero left the G %v, want Done", g.Status())
+	}
+
+	defer func() {
+		if r := recover(); r != "toysched: negative SchedWG counter" {
+			t.Fatalf("recovered %v", r)
+		}
+	}()
+	wg.Done()
+}